@@ -0,0 +1,465 @@
+package stats
+
+import "fmt"
+
+// Aggregator accumulates hierarchies one by one and calculates Stats from
+// the accumulated data. It allows to add and remove hierarchies without
+// processing all of them again. Aggregator is not safe for concurrent use.
+type Aggregator struct {
+	threshold float32
+	opts      options
+
+	// inputCount is the number of hierarchies given to the aggregator.
+	inputCount int
+
+	// namesNum is the number of qualified names.
+	namesNum int
+
+	// ranks contains counts of taxons for every rank.
+	ranks []rankData
+
+	// units keeps taxons that were counted for every species, if the
+	// UnitSpecies count unit is used.
+	units map[string]*unit
+
+	// children contains counts of names for every parent-child pair of
+	// taxons.
+	children map[Taxon]map[Taxon]int
+
+	// members contains qualified taxons of every counted name.
+	members []member
+
+	// missingRank is the number of names dropped because they did not have
+	// the rank required by OptRequireRank.
+	missingRank int
+
+	// sourcedNames is the sum of source weights of counted names, if
+	// OptSourceWeights is used.
+	sourcedNames float64
+
+	// treeErr is the first ErrInconsistentTree found by OptStrictTree.
+	treeErr error
+}
+
+// member is a counted name.
+type member struct {
+	taxons []Taxon
+	weight float64
+	source float64
+}
+
+// unit is a species that might be represented by several hierarchies.
+// Only the first of its names is counted.
+type unit struct {
+	names []member
+}
+
+// NewAggregator creates a new Aggregator. The threshold and options have
+// the same meaning as for New.
+func NewAggregator(threshold float32, opts ...Option) *Aggregator {
+	return newAggregator(threshold, newOptions(opts))
+}
+
+func newAggregator(threshold float32, o options) *Aggregator {
+	if !o.allowMinority {
+		if threshold < 0.5 {
+			threshold = 0.5
+		}
+		for k, v := range o.rankThresholds {
+			if v < 0.5 {
+				o.rankThresholds[k] = 0.5
+			}
+		}
+	}
+	return &Aggregator{
+		threshold: threshold,
+		opts:      o,
+		ranks:     ranksData(),
+		units:     make(map[string]*unit),
+		children:  make(map[Taxon]map[Taxon]int),
+	}
+}
+
+// Add adds a hierarchy to the aggregator. It returns true if the hierarchy
+// contributed to the rank counts.
+func (a *Aggregator) Add(h Hierarchy) bool {
+	a.inputCount++
+	taxons, ok, err := qualifiedTaxons(a.inputCount-1, h, a.opts)
+	if err != nil && a.treeErr == nil {
+		a.treeErr = fmt.Errorf("hierarchy %d: %w", a.inputCount-1, err)
+	}
+	if !ok {
+		if a.opts.lacksRequiredRank(taxons) {
+			a.missingRank++
+		}
+		return false
+	}
+
+	weight := hierarchyWeight(h)
+	source := a.sourceWeight(h)
+	if a.opts.countUnit == UnitSpecies {
+		key := speciesKey(taxons)
+		name := member{taxons: taxons, weight: weight, source: source}
+		if u, ok := a.units[key]; ok && key != "" {
+			u.names = append(u.names, name)
+			return false
+		}
+		if key != "" {
+			a.units[key] = &unit{names: []member{name}}
+		}
+	}
+
+	a.count(taxons, 1, weight, source)
+	return true
+}
+
+// Remove removes a previously added hierarchy from the aggregator. After
+// that the aggregator is in the same state as if the hierarchy had never
+// been added. It returns true if the rank counts changed. With UnitSpecies
+// only one name of a species is counted, if it is removed, the next name of
+// the species is counted instead. A qualified hierarchy that was never
+// given to Add is ignored and Remove returns false.
+func (a *Aggregator) Remove(h Hierarchy) bool {
+	if a.inputCount == 0 {
+		return false
+	}
+	a.inputCount--
+	// do not log the same events again
+	o := a.opts
+	o.logger = nil
+	taxons, ok, _ := qualifiedTaxons(a.inputCount, h, o)
+	if !ok {
+		if a.opts.lacksRequiredRank(taxons) {
+			a.missingRank--
+		}
+		return false
+	}
+
+	weight := hierarchyWeight(h)
+	source := a.sourceWeight(h)
+	if a.opts.countUnit == UnitSpecies {
+		key := speciesKey(taxons)
+		if u, ok := a.units[key]; ok {
+			changed, found := a.removeUnitName(key, u, member{
+				taxons: taxons, weight: weight, source: source,
+			})
+			if !found {
+				a.inputCount++
+			}
+			return changed
+		}
+	}
+
+	if !a.count(taxons, -1, weight, source) {
+		a.inputCount++
+		return false
+	}
+	return true
+}
+
+// removeUnitName removes a name from a unit. If the removed name was
+// the counted one, it is uncounted and the next name of the unit is
+// counted instead. It returns true if the counts changed, and whether the
+// name was found in the unit at all.
+func (a *Aggregator) removeUnitName(
+	key string,
+	u *unit,
+	name member,
+) (changed, found bool) {
+	// the search goes from the end, so a name that is the same as
+	// the counted one does not cause recounting
+	for i := len(u.names) - 1; i > 0; i-- {
+		if u.names[i].equal(name) {
+			u.names = append(u.names[:i], u.names[i+1:]...)
+			return false, true
+		}
+	}
+	if !u.names[0].equal(name) {
+		return false, false
+	}
+
+	cur := u.names[0]
+	a.count(cur.taxons, -1, cur.weight, cur.source)
+	u.names = u.names[1:]
+	if len(u.names) == 0 {
+		delete(a.units, key)
+		return true, true
+	}
+	next := u.names[0]
+	a.count(next.taxons, 1, next.weight, next.source)
+	return true, true
+}
+
+func (m member) equal(other member) bool {
+	return m.weight == other.weight && m.source == other.source &&
+		taxonsEqual(m.taxons, other.taxons)
+}
+
+// hierarchyWeight returns the weight of a hierarchy. Hierarchies that do
+// not implement WeightedHierarchy have weight 1.
+func hierarchyWeight(h Hierarchy) float64 {
+	wh, ok := h.(WeightedHierarchy)
+	if !ok {
+		return 1
+	}
+	if w := wh.Weight(); w > 0 {
+		return float64(w)
+	}
+	return 0
+}
+
+// sourceWeight returns the weight of the source of a hierarchy according
+// to OptSourceWeights. Hierarchies that do not implement Sourced, or come
+// from unknown sources, have weight 1.
+func (a *Aggregator) sourceWeight(h Hierarchy) float64 {
+	if a.opts.sourceWeights == nil {
+		return 1
+	}
+	sh, ok := h.(Sourced)
+	if !ok {
+		return 1
+	}
+	w, ok := a.opts.sourceWeights[sh.Source()]
+	if !ok {
+		return 1
+	}
+	if w > 0 {
+		return float64(w)
+	}
+	return 0
+}
+
+// count adds delta to the counts of all taxons of a qualified name, and
+// adds or subtracts the weight and the source weight of the name. A name
+// that is not among the members cannot be subtracted, and count returns
+// false for it.
+func (a *Aggregator) count(
+	taxons []Taxon,
+	delta int,
+	weight, source float64,
+) bool {
+	m := member{taxons: taxons, weight: weight, source: source}
+	if !a.updateMembers(m, delta) {
+		return false
+	}
+	a.namesNum += delta
+	weight *= float64(delta)
+	source *= float64(delta)
+	useSource := a.opts.sourceWeights != nil
+	if useSource {
+		a.sourcedNames += source
+	}
+	for i := range taxons {
+		rd := &a.ranks[taxons[i].Index()]
+		if rd.weights == nil {
+			rd.weights = make(map[Taxon]float64)
+		}
+		if useSource && rd.sourced == nil {
+			rd.sourced = make(map[Taxon]float64)
+		}
+		rd.data[taxons[i]] += delta
+		rd.total += delta
+		rd.weights[taxons[i]] += weight
+		rd.weightTotal += weight
+		if useSource {
+			rd.sourced[taxons[i]] += source
+		}
+		if rd.data[taxons[i]] <= 0 {
+			delete(rd.data, taxons[i])
+			delete(rd.weights, taxons[i])
+			delete(rd.sourced, taxons[i])
+		}
+		if rd.total <= 0 {
+			rd.weightTotal = 0
+		}
+
+		if i == 0 {
+			continue
+		}
+		parent := taxons[i-1]
+		cs, ok := a.children[parent]
+		if !ok {
+			cs = make(map[Taxon]int)
+			a.children[parent] = cs
+		}
+		cs[taxons[i]] += delta
+		if cs[taxons[i]] <= 0 {
+			delete(cs, taxons[i])
+		}
+		if len(cs) == 0 {
+			delete(a.children, parent)
+		}
+	}
+	return true
+}
+
+// updateMembers adds a name to the members, or removes it if delta is
+// negative. It returns false if the name to remove is not a member.
+func (a *Aggregator) updateMembers(m member, delta int) bool {
+	if delta > 0 {
+		a.members = append(a.members, m)
+		return true
+	}
+	for i := range a.members {
+		if a.members[i].equal(m) {
+			a.members = append(a.members[:i], a.members[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func taxonsEqual(t1, t2 []Taxon) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i := range t1 {
+		if t1[i] != t2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats calculates Stats from the accumulated data.
+func (a *Aggregator) Stats() Stats {
+	var res Stats
+	a.StatsInto(&res)
+	return res
+}
+
+// StatsInto calculates Stats from the accumulated data and writes them
+// into dst, reusing its memory. The previous content of dst is discarded.
+func (a *Aggregator) StatsInto(dst *Stats) {
+	if b := a.withRepresentatives(); b != nil {
+		b.statsInto(dst)
+		return
+	}
+	a.statsInto(dst)
+}
+
+// withRepresentatives returns a copy of the aggregator where canonicalized
+// taxons are replaced by their representatives (see OptCanonicalizeKingdoms).
+// It returns nil if there is nothing to replace.
+func (a *Aggregator) withRepresentatives() *Aggregator {
+	if !a.opts.canonicalAll && !a.opts.canonicalKingdoms {
+		return nil
+	}
+	names := make([][]Taxon, len(a.members))
+	for i := range a.members {
+		names[i] = a.members[i].taxons
+	}
+	reps := a.opts.representatives(names)
+	if reps == nil {
+		return nil
+	}
+	res := newAggregator(a.threshold, a.opts)
+	res.inputCount = a.inputCount
+	res.missingRank = a.missingRank
+	for _, m := range a.members {
+		res.count(replaceTaxons(m.taxons, reps), 1, m.weight, m.source)
+	}
+	return res
+}
+
+func (a *Aggregator) statsInto(dst *Stats) {
+	dst.Reset()
+	dst.InputCount = a.inputCount
+	dst.threshold = a.threshold
+	dst.opts = a.opts
+	switch a.namesNum {
+	case 0:
+		dst.DroppedNames = a.inputCount
+		dst.EmptyReason = ReasonNoNames
+		a.addWarnings(dst)
+		return
+	case 1:
+		dst.DroppedNames = a.inputCount
+		dst.EmptyReason = ReasonSingleName
+		a.addWarnings(dst)
+		return
+	}
+
+	if a.opts.inspect != nil {
+		for i := range a.ranks {
+			counts := make(map[string]int, len(a.ranks[i].data))
+			for k, v := range a.ranks[i].data {
+				counts[k.Name] += v
+			}
+			a.opts.inspect(a.ranks[i].rank, counts)
+		}
+	}
+
+	for i := range a.ranks {
+		a.ranks[i].sourcedNames = a.sourcedNames
+	}
+	a.countResolved(dst)
+	ranks := removeEmptyRanks(a.ranks, a.opts.minRankSamples)
+	calcStats(dst, a.namesNum, ranks, a.threshold, a.opts)
+	if dst.children == nil {
+		dst.children = make(map[Taxon]map[Taxon]int, len(a.children))
+	}
+	for k, v := range a.children {
+		dst.children[k] = copyCounts(v)
+	}
+	dst.members = append(dst.members, a.members...)
+	dst.DroppedNames = a.inputCount - a.namesNum
+	a.addWarnings(dst)
+}
+
+// countResolved counts names that have a taxon of every rank. A name
+// with several taxons of the same rank is counted once. Without data about
+// names (see NewFromCounts) the totals of ranks are used instead.
+func (a *Aggregator) countResolved(dst *Stats) {
+	if dst.resolved == nil {
+		dst.resolved = make(map[Rank]int, len(a.ranks))
+	}
+	if len(a.members) == 0 {
+		for i := range a.ranks {
+			if a.ranks[i].total > 0 {
+				dst.resolved[a.ranks[i].rank] = min(a.ranks[i].total, a.namesNum)
+			}
+		}
+		return
+	}
+	for _, m := range a.members {
+		for i, v := range m.taxons {
+			if i > 0 && m.taxons[i-1].Rank == v.Rank {
+				continue
+			}
+			dst.resolved[v.Rank]++
+		}
+	}
+}
+
+// addWarnings adds warnings about dropped names and unrecognized ranks.
+func (a *Aggregator) addWarnings(dst *Stats) {
+	if dst.DroppedNames > 0 {
+		dst.addWarning(WarnDroppedNames,
+			"%d of %d names did not qualify for the calculation",
+			dst.DroppedNames, dst.InputCount)
+	}
+	if a.missingRank > 0 {
+		dst.addWarning(WarnMissingRank,
+			"%d names did not have the required rank %s",
+			a.missingRank, a.opts.requireRank.String())
+	}
+	var unknown int
+	for k, v := range a.ranks[Unknown.Index()].data {
+		if !isUnranked(k.RankStr) {
+			unknown += v
+		}
+	}
+	if unknown > 0 {
+		dst.addWarning(WarnUnknownRanks,
+			"%d taxa have unrecognized ranks", unknown)
+	}
+}
+
+func copyCounts(m map[Taxon]int) map[Taxon]int {
+	res := make(map[Taxon]int, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,222 @@
+package stats_test
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregator(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	a := stats.NewAggregator(0.5)
+	for i := range hs {
+		a.Add(hs[i])
+	}
+	assertStatsEqual(t, stats.New(hs, 0.5), a.Stats())
+	assert.Equal("Squamata", a.Stats().MainTaxon.Name)
+}
+
+func TestNewFromChan(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	ch := make(chan stats.Hierarchy)
+	go func() {
+		defer close(ch)
+		for i := range hs {
+			ch <- hs[i]
+		}
+	}()
+	res, err := stats.NewFromChan(context.Background(), ch, 0.5)
+	assert.Nil(err)
+	assertStatsEqual(t, stats.New(hs, 0.5), res)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stats.NewFromChan(ctx, make(chan stats.Hierarchy), 0.5)
+	assert.ErrorIs(err, context.Canceled)
+}
+
+func TestNewFromCounts(t *testing.T) {
+	assert := assert.New(t)
+	var hs []stats.Hierarchy
+	counts := make(map[stats.Rank]map[stats.Taxon]int)
+	for _, h := range taxons2(t, "reptiles.csv") {
+		var qualified bool
+		for _, v := range h.Taxons() {
+			if v.WithResolvedRank().Between(stats.SubSpecies, stats.Genus) {
+				qualified = true
+			}
+		}
+		if !qualified {
+			continue
+		}
+		hs = append(hs, h)
+		for _, v := range h.Taxons() {
+			v = v.WithResolvedRank()
+			if counts[v.Rank] == nil {
+				counts[v.Rank] = make(map[stats.Taxon]int)
+			}
+			counts[v.Rank][v]++
+		}
+	}
+	exp := stats.New(hs, 0.5)
+	res := stats.NewFromCounts(counts, len(hs), 0.5)
+	assert.Equal(exp.DroppedNames, 0)
+
+	// apart from the warning there is no difference in exported data
+	warn := res.Warnings[len(res.Warnings)-1]
+	assert.Equal(stats.WarnNoNameData, warn.Code)
+	res.Warnings = res.Warnings[:len(res.Warnings)-1]
+	assertStatsEqual(t, exp, res)
+
+	expJSON, err := json.Marshal(exp)
+	assert.Nil(err)
+	resJSON, err := json.Marshal(res)
+	assert.Nil(err)
+	assert.Equal(string(expJSON), string(resJSON))
+	for _, r := range stats.Ranks() {
+		assert.Equal(exp.Distribution(r), res.Distribution(r), r.String())
+	}
+	assert.Empty(res.ChildShares("Squamata"))
+	assert.Empty(res.OutlierNames(exp.MainTaxon.ID))
+	assert.Nil(res.Rarefaction(stats.Family, 10, 1))
+	assert.Equal(0.0, res.RankWeightedDiversity())
+	assert.Equal(stats.ReasonNoNames, res.DrillDown().EmptyReason)
+	assert.NotEmpty(exp.ChildShares("Squamata"))
+	assert.NotEmpty(exp.OutlierNames(exp.MainTaxon.ID))
+
+	res = stats.NewFromCounts(nil, 0, 0.5)
+	assert.Equal(stats.ReasonNoNames, res.EmptyReason)
+	assert.Empty(res.Warnings)
+}
+
+func TestAggregatorRemove(t *testing.T) {
+	tests := []struct {
+		msg  string
+		file string
+		opts []stats.Option
+	}{
+		{"molluscs", "", nil},
+		{"reptiles", "reptiles.csv", nil},
+		{
+			"species", "reptiles.csv",
+			[]stats.Option{stats.OptCountUnit(stats.UnitSpecies)},
+		},
+	}
+	for _, v := range tests {
+		var hs []stats.Hierarchy
+		if v.file == "" {
+			hs = testData(t)
+		} else {
+			hs = taxons2(t, v.file)
+		}
+		l := len(hs) - 20
+		ab := stats.NewAggregator(0.5, v.opts...)
+		abc := stats.NewAggregator(0.5, v.opts...)
+		for i := range hs {
+			abc.Add(hs[i])
+			if i < l {
+				ab.Add(hs[i])
+			}
+		}
+		for i := l; i < len(hs); i++ {
+			abc.Remove(hs[i])
+		}
+		assertStatsEqual(t, ab.Stats(), abc.Stats(), v.msg)
+		assert.Equal(t, l, abc.Stats().InputCount, v.msg)
+	}
+}
+
+func TestAggregatorRemoveUnitSpecies(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|family|genus|species"
+	h0 := newHry("Animalia|Mollusca|Gastropoda|Muricidae|Murex|Murex pecten",
+		ranks, "N|M|G|MU|MX|1")
+	h1 := newHry("Animalia|Mollusca|Gastropoda|Otherfam|Murex|Murex pecten",
+		ranks, "N|M|G|OF|MX|1")
+	h2 := newHry("Animalia|Mollusca|Gastropoda|Conidae|Conus|Conus textile",
+		ranks, "N|M|G|CO|CN|2")
+	opt := stats.OptCountUnit(stats.UnitSpecies)
+
+	a := stats.NewAggregator(0.5, opt)
+	a.Add(h0)
+	a.Add(h1)
+	a.Add(h2)
+	assert.False(a.Remove(h1))
+	exp := stats.New([]stats.Hierarchy{h0, h2}, 0.5, opt)
+	assert.True(exp.Equal(a.Stats()))
+
+	a = stats.NewAggregator(0.5, opt)
+	a.Add(h0)
+	a.Add(h1)
+	a.Add(h2)
+	assert.True(a.Remove(h0))
+	exp = stats.New([]stats.Hierarchy{h1, h2}, 0.5, opt)
+	res := a.Stats()
+	assert.True(exp.Equal(res))
+	for _, v := range res.Distribution(stats.Family) {
+		assert.NotEqual("Muricidae", v.Name)
+	}
+
+	// a name of a counted species that was never added changes nothing
+	a = stats.NewAggregator(0.5, opt)
+	a.Add(h0)
+	a.Add(h1)
+	a.Add(h2)
+	never := newHry("Animalia|Mollusca|Gastropoda|Tytonidae|Murex|Murex pecten",
+		ranks, "N|M|G|TY|MX|1")
+	assert.False(a.Remove(never))
+	res = a.Stats()
+	assert.Equal(2, res.NamesNum)
+	assert.Equal(3, res.InputCount)
+	exp = stats.New([]stats.Hierarchy{h0, h1, h2}, 0.5, opt)
+	assert.True(exp.Equal(res))
+
+	// the same for a name of a species that is not in the aggregator
+	never = newHry("Animalia|Chordata|Aves|Strigidae|Bubo|Bubo bubo",
+		ranks, "N|C|AV|ST|BU|3")
+	assert.False(a.Remove(never))
+	assert.True(exp.Equal(a.Stats()))
+
+	a = stats.NewAggregator(0.5, opt)
+	a.Add(h0)
+	a.Add(h1)
+	a.Add(h2)
+	assert.True(a.Remove(h0))
+	assert.True(a.Remove(h1))
+	assert.Equal(stats.ReasonSingleName, a.Stats().EmptyReason)
+}
+
+// assertStatsEqual compares Stats ignoring the order of Kingdoms and
+// small float differences in entropies.
+func assertStatsEqual(t *testing.T, exp, res stats.Stats, msg ...string) {
+	expEnt, resEnt := exp.Entropies(), res.Entropies()
+	assert.Equal(t, len(expEnt), len(resEnt), msg)
+	for k, v := range expEnt {
+		assert.InDelta(t, v, resEnt[k], 1e-9, msg)
+	}
+	assert.Equal(t, exp.Coherence(), res.Coherence(), msg)
+
+	sortKingdoms := func(s *stats.Stats) {
+		sort.Slice(s.Kingdoms, func(i, j int) bool {
+			return s.Kingdoms[i].Name < s.Kingdoms[j].Name
+		})
+	}
+	sortKingdoms(&exp)
+	sortKingdoms(&res)
+	assert.Equal(t, exp.Kingdoms, res.Kingdoms, msg)
+	assert.Equal(t, exp.Entries(), res.Entries(), msg)
+	assert.Equal(t, exp.NamesNum, res.NamesNum, msg)
+	assert.Equal(t, exp.InputCount, res.InputCount, msg)
+	assert.Equal(t, exp.DroppedNames, res.DroppedNames, msg)
+	assert.Equal(t, exp.MainTaxon, res.MainTaxon, msg)
+	assert.Equal(t, exp.MainTaxonPercentage, res.MainTaxonPercentage, msg)
+	assert.Equal(t, exp.MainTaxonIsComplete, res.MainTaxonIsComplete, msg)
+	assert.Equal(t, exp.NamesOutsideMainTaxon, res.NamesOutsideMainTaxon, msg)
+	assert.Equal(t, exp.EmptyReason, res.EmptyReason, msg)
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"runtime"
+	"sync"
+)
+
+// NewBatch calculates Stats for every group of hierarchies. Groups are
+// processed in parallel by a pool of workers, its size is set by
+// OptConcurrency. The result is aligned with the groups: the Stats
+// with index i belong to the group with index i. Degenerate groups
+// receive empty Stats with the corresponding EmptyReason.
+func NewBatch(
+	groups [][]Hierarchy,
+	threshold float32,
+	opts ...Option,
+) []Stats {
+	res := make([]Stats, len(groups))
+	workers := newOptions(opts).concurrency
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(groups) {
+		workers = len(groups)
+	}
+
+	idxs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range idxs {
+				res[idx] = New(groups[idx], threshold, opts...)
+			}
+		}()
+	}
+
+	for i := range groups {
+		idxs <- i
+	}
+	close(idxs)
+	wg.Wait()
+	return res
+}
+
+// NewByTag groups hierarchies by their tags (see Tagged) and calculates
+// Stats for every group in parallel (see NewBatch). Hierarchies that do not implement
+// Tagged belong to the group with the empty tag. The order of hierarchies
+// inside a group is preserved.
+func NewByTag(
+	h []Hierarchy,
+	threshold float32,
+	opts ...Option,
+) map[string]Stats {
+	var tags []string
+	groups := make(map[string][]Hierarchy)
+	for i := range h {
+		var tag string
+		if t, ok := h[i].(Tagged); ok {
+			tag = t.Tag()
+		}
+		if _, ok := groups[tag]; !ok {
+			tags = append(tags, tag)
+		}
+		groups[tag] = append(groups[tag], h[i])
+	}
+
+	batch := make([][]Hierarchy, len(tags))
+	for i, tag := range tags {
+		batch[i] = groups[tag]
+	}
+	calculated := NewBatch(batch, threshold, opts...)
+	res := make(map[string]Stats, len(tags))
+	for i, tag := range tags {
+		res[tag] = calculated[i]
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+package stats_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func batchGroups(t testing.TB) [][]stats.Hierarchy {
+	reptiles := taxons2(t, "reptiles.csv")
+	fishes := taxons2(t, "taxons2.csv")
+	return [][]stats.Hierarchy{
+		testData(t),
+		reptiles,
+		fishes,
+		nil,
+		fishes[:1],
+		fiftyFifty(),
+		reptiles[100:200],
+	}
+}
+
+func TestNewBatch(t *testing.T) {
+	assert := assert.New(t)
+	groups := batchGroups(t)
+	res := stats.NewBatch(groups, 0.5)
+	assert.Equal(len(groups), len(res))
+	for i := range groups {
+		assert.True(res[i].Equal(stats.New(groups[i], 0.5)), i)
+	}
+	assert.Equal("Gastropoda", res[0].MainTaxon.Name)
+	assert.Equal("Squamata", res[1].MainTaxon.Name)
+	assert.Equal(stats.ReasonNoNames, res[3].EmptyReason)
+	assert.Equal(stats.ReasonSingleName, res[4].EmptyReason)
+
+	assert.Empty(stats.NewBatch(nil, 0.5))
+}
+
+func TestOptConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	var groups [][]stats.Hierarchy
+	for i := 0; i < 5; i++ {
+		groups = append(groups, batchGroups(t)...)
+	}
+	serial := stats.NewBatch(groups, 0.5, stats.OptConcurrency(1))
+	for _, n := range []int{0, 2, 8, 100} {
+		res := stats.NewBatch(groups, 0.5, stats.OptConcurrency(n))
+		assert.Equal(len(serial), len(res))
+		for i := range res {
+			assert.True(serial[i].Equal(res[i]), i)
+		}
+	}
+
+	var hs []stats.Hierarchy
+	for _, h := range testData(t) {
+		hs = append(hs, tagged{h, h.Taxons()[3].Name})
+	}
+	exp := stats.NewByTag(hs, 0.5, stats.OptConcurrency(1))
+	res := stats.NewByTag(hs, 0.5, stats.OptConcurrency(4))
+	assert.Equal(len(exp), len(res))
+	for k, v := range exp {
+		assert.True(v.Equal(res[k]), k)
+	}
+}
+
+type tagged struct {
+	stats.Hierarchy
+	tag string
+}
+
+func (t tagged) Tag() string {
+	return t.tag
+}
+
+func TestNewByTag(t *testing.T) {
+	assert := assert.New(t)
+	molluscs := testData(t)
+	reptiles := taxons2(t, "reptiles.csv")
+	var hs []stats.Hierarchy
+	for i := 0; i < len(molluscs) || i < len(reptiles); i++ {
+		if i < len(molluscs) {
+			hs = append(hs, tagged{molluscs[i], "doc1"})
+		}
+		if i < len(reptiles) {
+			hs = append(hs, tagged{reptiles[i], "doc2"})
+		}
+	}
+	res := stats.NewByTag(hs, 0.5)
+	assert.Equal(2, len(res))
+	assert.True(res["doc1"].Equal(stats.New(molluscs, 0.5)))
+	assert.True(res["doc2"].Equal(stats.New(reptiles, 0.5)))
+	assert.Equal("Gastropoda", res["doc1"].MainTaxon.Name)
+	assert.Equal("Squamata", res["doc2"].MainTaxon.Name)
+
+	hs = append(hs, fiftyFifty()...)
+	res = stats.NewByTag(hs, 0.5)
+	assert.Equal(3, len(res))
+	assert.True(res[""].Equal(stats.New(fiftyFifty(), 0.5)))
+
+	assert.Empty(stats.NewByTag(nil, 0.5))
+}
+
+func BenchmarkNewBatch(b *testing.B) {
+	var groups [][]stats.Hierarchy
+	for i := 0; i < 10; i++ {
+		groups = append(groups, batchGroups(b)...)
+	}
+	b.Run("Loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for ii := range groups {
+				_ = stats.New(groups[ii], 0.5)
+			}
+		}
+	})
+	b.Run("Batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = stats.NewBatch(groups, 0.5)
+		}
+	})
+	for _, n := range []int{1, 2, 4} {
+		b.Run(fmt.Sprintf("Concurrency%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = stats.NewBatch(groups, 0.5, stats.OptConcurrency(n))
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// BootstrapResult contains information about the stability of MainTaxon
+// calculated from resampled hierarchies.
+type BootstrapResult struct {
+	// Iterations is the number of resamples.
+	Iterations int
+
+	// NoMainTaxon is the number of resamples where MainTaxon was not found.
+	NoMainTaxon int
+
+	// MainTaxa contains all taxa that were found as MainTaxon, sorted by
+	// the number of times they were found.
+	MainTaxa []BootstrapTaxon
+}
+
+// BootstrapTaxon describes how often a taxon was found as MainTaxon.
+type BootstrapTaxon struct {
+	// Taxon is the MainTaxon of one or more resamples.
+	Taxon
+
+	// Count is the number of resamples where the taxon was MainTaxon.
+	Count int
+
+	// MeanPercentage is the mean of MainTaxonPercentage for resamples where
+	// the taxon was MainTaxon.
+	MeanPercentage float32
+}
+
+// Bootstrap resamples hierarchies with replacement the given number of
+// iterations, calculates stats for every resample and reports how often
+// each taxon was the MainTaxon. The seed makes results reproducible.
+func Bootstrap(
+	h []Hierarchy,
+	iterations int,
+	seed int64,
+	threshold float32,
+	opts ...Option,
+) BootstrapResult {
+	res := BootstrapResult{Iterations: iterations}
+	if len(h) == 0 || iterations < 1 {
+		return res
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	sample := make([]Hierarchy, len(h))
+	counts := make(map[Taxon]int)
+	sums := make(map[Taxon]float32)
+	for i := 0; i < iterations; i++ {
+		for ii := range sample {
+			sample[ii] = h[rnd.Intn(len(h))]
+		}
+		st := New(sample, threshold, opts...)
+		if st.MainTaxon.IsZero() {
+			res.NoMainTaxon++
+			continue
+		}
+		counts[st.MainTaxon]++
+		sums[st.MainTaxon] += st.MainTaxonPercentage
+	}
+
+	res.MainTaxa = make([]BootstrapTaxon, 0, len(counts))
+	for k, v := range counts {
+		res.MainTaxa = append(res.MainTaxa, BootstrapTaxon{
+			Taxon:          k,
+			Count:          v,
+			MeanPercentage: sums[k] / float32(v),
+		})
+	}
+	sort.Slice(res.MainTaxa, func(i, j int) bool {
+		ti, tj := res.MainTaxa[i], res.MainTaxa[j]
+		if ti.Count != tj.Count {
+			return ti.Count > tj.Count
+		}
+		if ti.Rank != tj.Rank {
+			return ti.Rank > tj.Rank
+		}
+		return ti.Name < tj.Name
+	})
+	return res
+}
@@ -0,0 +1,28 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrap(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res1 := stats.Bootstrap(hs, 50, 42, 0.5)
+	res2 := stats.Bootstrap(hs, 50, 42, 0.5)
+	assert.Equal(res1, res2)
+	assert.Equal(50, res1.Iterations)
+
+	total := res1.NoMainTaxon
+	for _, v := range res1.MainTaxa {
+		total += v.Count
+		assert.Greater(v.MeanPercentage, float32(0.5))
+	}
+	assert.Equal(50, total)
+	assert.Equal("Gastropoda", res1.MainTaxa[0].Name)
+
+	res := stats.Bootstrap(nil, 10, 42, 0.5)
+	assert.Empty(res.MainTaxa)
+}
@@ -0,0 +1,49 @@
+package stats
+
+// CalibrateThreshold finds the MainTaxon threshold that recovers expected
+// main taxa best. Every group of hierarchies has a corresponding expected
+// MainTaxon, given by its name or ID, an empty string means that no
+// MainTaxon is expected. Stats are calculated for every group with every
+// candidate threshold. It returns the candidate with the highest accuracy
+// (the fraction of groups where the MainTaxon matches the expectation)
+// together with the accuracy. If several candidates have the same
+// accuracy, the first of them is returned. Groups without a corresponding
+// element in expected are ignored.
+func CalibrateThreshold(
+	groups [][]Hierarchy,
+	expected []string,
+	candidates []float32,
+	opts ...Option,
+) (float32, float64) {
+	if len(expected) < len(groups) {
+		groups = groups[:len(expected)]
+	}
+	if len(groups) == 0 || len(candidates) == 0 {
+		return 0, 0
+	}
+
+	var res float32
+	max := -1.0
+	for _, threshold := range candidates {
+		var matches int
+		for i, s := range NewBatch(groups, threshold, opts...) {
+			if isExpectedTaxon(s.MainTaxon, expected[i]) {
+				matches++
+			}
+		}
+		accuracy := float64(matches) / float64(len(groups))
+		if accuracy > max {
+			res, max = threshold, accuracy
+		}
+	}
+	return res, max
+}
+
+// isExpectedTaxon checks if the name or the ID of a taxon are the same as
+// the expected value. An empty expected value matches only a zero taxon.
+func isExpectedTaxon(t Taxon, expected string) bool {
+	if expected == "" {
+		return t.IsZero()
+	}
+	return t.Name == expected || (t.ID != "" && t.ID == expected)
+}
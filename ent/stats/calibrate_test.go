@@ -0,0 +1,36 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrateThreshold(t *testing.T) {
+	assert := assert.New(t)
+	groups := [][]stats.Hierarchy{testData(t), taxons2(t, "reptiles.csv")}
+	candidates := []float32{0.5, 0.6, 0.95}
+
+	threshold, accuracy := stats.CalibrateThreshold(
+		groups, []string{"Mollusca", "Squamata"}, candidates,
+	)
+	assert.Equal(float32(0.6), threshold)
+	assert.Equal(1.0, accuracy)
+
+	threshold, accuracy = stats.CalibrateThreshold(
+		groups, []string{"Gastropoda", "Squamata"}, candidates,
+	)
+	assert.Equal(float32(0.5), threshold)
+	assert.Equal(1.0, accuracy)
+
+	threshold, accuracy = stats.CalibrateThreshold(
+		groups, []string{"Gastropoda", "Reptilia"}, candidates,
+	)
+	assert.Equal(float32(0.5), threshold)
+	assert.Equal(0.5, accuracy)
+
+	threshold, accuracy = stats.CalibrateThreshold(nil, nil, candidates)
+	assert.Equal(float32(0), threshold)
+	assert.Equal(0.0, accuracy)
+}
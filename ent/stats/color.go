@@ -0,0 +1,34 @@
+package stats
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// KingdomColors is the palette used by KingdomColor for kingdoms of
+// the Catalogue of Life. It can be modified to override the default
+// colors.
+var KingdomColors = map[string]string{
+	"Animalia":  "#e6550d",
+	"Plantae":   "#31a354",
+	"Fungi":     "#8c6d31",
+	"Bacteria":  "#3182bd",
+	"Archaea":   "#756bb1",
+	"Chromista": "#17becf",
+	"Protozoa":  "#e377c2",
+	"Viruses":   "#636363",
+}
+
+// KingdomColor returns a hex color (for example "#31a354") for the name of
+// a kingdom to keep colors of kingdoms consistent in visualizations. Names
+// from KingdomColors get their colors from the palette, colors of other
+// names are derived from an FNV-1a hash of the name, so they are stable
+// between calls.
+func KingdomColor(name string) string {
+	if color, ok := KingdomColors[name]; ok {
+		return color
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return fmt.Sprintf("#%06x", h.Sum32()&0xffffff)
+}
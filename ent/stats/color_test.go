@@ -0,0 +1,28 @@
+package stats_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKingdomColor(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("#e6550d", stats.KingdomColor("Animalia"))
+	assert.Equal("#31a354", stats.KingdomColor("Plantae"))
+	assert.Equal("#8c6d31", stats.KingdomColor("Fungi"))
+	assert.Equal("#3182bd", stats.KingdomColor("Bacteria"))
+
+	hexRe := regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	unknown := stats.KingdomColor("Metazoa")
+	assert.Regexp(hexRe, unknown)
+	assert.Equal(unknown, stats.KingdomColor("Metazoa"))
+	assert.NotEqual(unknown, stats.KingdomColor("Mycota"))
+	assert.Regexp(hexRe, stats.KingdomColor(""))
+
+	stats.KingdomColors["Metazoa"] = "#000000"
+	defer delete(stats.KingdomColors, "Metazoa")
+	assert.Equal("#000000", stats.KingdomColor("Metazoa"))
+}
@@ -0,0 +1,214 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// WeightedStats is Stats with a weight that defines how much they
+// contribute to combined Stats, for example the number of names resolved
+// by a particular release of the Catalogue of Life.
+type WeightedStats struct {
+	Stats
+
+	// Weight of the Stats. Stats with zero or negative weight are ignored
+	// for the determination of taxons and percentages.
+	Weight float32
+}
+
+// CombineStats merges already calculated Stats into one summary.
+// Percentages of taxons of kingdom, phylum, class, order, family and genus
+// are weighted averages of percentages of the given Stats. Prevalent taxons
+// are the ones with the highest weighted percentage, MainTaxon is the lowest
+// of them that contains more than half of the names. As in New, tied
+// kingdoms are resolved by ID and name and set KingdomTie, ties of other
+// ranks leave their taxons empty. NamesNum, InputCount and DroppedNames are
+// sums of the corresponding values.
+//
+// The result is an approximation. Stats that were decoded from JSON
+// only contribute their prevalent taxons, kingdoms and MainTaxon. Diversity
+// indices, distributions of other ranks and lineage data are not
+// available in the combined Stats.
+func CombineStats(weighted []WeightedStats) Stats {
+	var res Stats
+	var weightTotal float64
+	for i := range weighted {
+		res.NamesNum += weighted[i].NamesNum
+		res.InputCount += weighted[i].InputCount
+		res.DroppedNames += weighted[i].DroppedNames
+		if weighted[i].Weight > 0 && weighted[i].NamesNum > 0 {
+			weightTotal += float64(weighted[i].Weight)
+		}
+	}
+	if weightTotal == 0 {
+		res.EmptyReason = ReasonNoNames
+		return res
+	}
+
+	shares := make(map[Rank]map[Taxon]float64, len(majorRanks))
+	names := make(map[Rank]map[Taxon]int, len(majorRanks))
+	for _, r := range majorRanks {
+		shares[r] = make(map[Taxon]float64)
+		names[r] = make(map[Taxon]int)
+	}
+
+	var resolution float64
+	for i := range weighted {
+		ws := weighted[i]
+		if ws.Weight <= 0 || ws.NamesNum == 0 {
+			continue
+		}
+		k := float64(ws.Weight) / weightTotal
+		resolution += k * float64(ws.GenusResolutionRate)
+		for _, r := range majorRanks {
+			for txn, num := range ws.rankNames(r) {
+				shares[r][txn] += k * ws.rankShare(r, txn, num)
+				names[r][txn] += num
+			}
+		}
+	}
+	res.GenusResolutionRate = float32(resolution)
+
+	var kingdomNames int
+	for _, v := range names[Kingdom] {
+		kingdomNames += v
+	}
+	for txn, share := range shares[Kingdom] {
+		td := TaxonDist{
+			NamesNum:   names[Kingdom][txn],
+			ID:         txn.ID,
+			Name:       txn.Name,
+			Percentage: float32(share),
+		}
+		if kingdomNames > 0 {
+			td.CoverageShare = float32(td.NamesNum) / float32(kingdomNames)
+		}
+		res.Kingdoms = append(res.Kingdoms, td)
+	}
+	sortTaxDist(res.Kingdoms)
+
+	var foundMainTaxon bool
+	for i := len(majorRanks) - 1; i >= 0; i-- {
+		r := majorRanks[i]
+		txn, share, tie := maxShare(shares[r])
+		if share == 0 {
+			continue
+		}
+		if tie {
+			if res.CoDominant == nil {
+				res.CoDominant = make(map[Rank][]Taxon)
+			}
+			tied := tiedShares(shares[r], share)
+			res.CoDominant[r] = tied
+			if r != Kingdom {
+				res.addWarning(WarnTie,
+					"%d taxa share the highest percentage of %s rank",
+					len(tied), r)
+				continue
+			}
+			res.KingdomTie = true
+		}
+		res.setPrevalent(txn, float32(share))
+		if !foundMainTaxon && !tie && share > 0.5 {
+			foundMainTaxon = true
+			res.MainTaxon = txn
+			res.MainTaxonPercentage = float32(share)
+			res.NamesOutsideMainTaxon = res.NamesNum - names[r][txn]
+			res.MainTaxonIsComplete = res.NamesOutsideMainTaxon == 0
+		}
+	}
+	return res
+}
+
+// rankNames returns the number of names for taxons of the given rank.
+// If detailed counts are not available, it uses the prevalent taxon,
+// MainTaxon and, for kingdoms, the Kingdoms distribution.
+func (s Stats) rankNames(rank Rank) map[Taxon]int {
+	if counts, ok := s.rankCounts[rank]; ok {
+		return counts
+	}
+	res := make(map[Taxon]int)
+	if rank == Kingdom {
+		for _, v := range s.Kingdoms {
+			txn := Taxon{ID: v.ID, Name: v.Name, Rank: Kingdom}
+			if v.ID == s.Kingdom.ID && v.Name == s.Kingdom.Name {
+				txn = s.Kingdom
+			}
+			res[txn] = v.NamesNum
+		}
+	}
+	add := func(txn Taxon, pcent float32) {
+		if _, ok := res[txn]; ok || txn.IsZero() || txn.Rank != rank {
+			return
+		}
+		res[txn] = int(math.Round(float64(pcent) * float64(s.NamesNum)))
+	}
+	add(s.Prevalent(rank))
+	add(s.MainTaxon, s.MainTaxonPercentage)
+	return res
+}
+
+// rankShare returns the share of names of a taxon, num is the number of
+// its names. If source weights were used, the share is calculated from
+// them.
+func (s Stats) rankShare(rank Rank, txn Taxon, num int) float64 {
+	if sourced, ok := s.rankSourced[rank]; ok && s.sourcedNames > 0 {
+		return sourced[txn] / s.sourcedNames
+	}
+	return float64(num) / float64(s.NamesNum)
+}
+
+// maxShare returns the taxon with the highest share and true if several
+// taxons share the highest value. Ties are resolved by ID and then by
+// name, the same way as for Stats.Kingdom. It returns a zero share if
+// there are no taxons.
+func maxShare(shares map[Taxon]float64) (Taxon, float64, bool) {
+	var res Taxon
+	var max float64
+	var tie bool
+	for k, v := range shares {
+		switch {
+		case v > max:
+			res, max, tie = k, v, false
+		case v == max && v > 0:
+			tie = true
+			if taxonLess(k, res) {
+				res = k
+			}
+		}
+	}
+	return res, max, tie
+}
+
+// tiedShares returns taxons that have the given share, sorted by ID and
+// name.
+func tiedShares(shares map[Taxon]float64, share float64) []Taxon {
+	var res []Taxon
+	for k, v := range shares {
+		if v == share {
+			res = append(res, k)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return taxonLess(res[i], res[j])
+	})
+	return res
+}
+
+// setPrevalent sets the prevalent taxon of its rank and its percentage.
+func (s *Stats) setPrevalent(txn Taxon, pcent float32) {
+	switch txn.Rank {
+	case Kingdom:
+		s.Kingdom, s.KingdomPercentage = txn, pcent
+	case Phylum:
+		s.Phylum, s.PhylumPercentage = txn, pcent
+	case Class:
+		s.Class, s.ClassPercentage = txn, pcent
+	case Order:
+		s.Order, s.OrderPercentage = txn, pcent
+	case Family:
+		s.Family, s.FamilyPercentage = txn, pcent
+	case Genus:
+		s.Genus, s.GenusPercentage = txn, pcent
+	}
+}
@@ -0,0 +1,88 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombineStats(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus"
+	animals := stats.New([]stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo", ranks,
+			"N|CH2|V2|466|GQX|3DQQ"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Strix", ranks,
+			"N|CH2|V2|466|GQX|6W7S"),
+	}, 0.5)
+	plants := stats.New([]stats.Hierarchy{
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Lamiales|Plantaginaceae|Plantago",
+			ranks, "P|TP|MG|LM|PL|PT"),
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Lamiales|Plantaginaceae|Veronica",
+			ranks, "P|TP|MG|LM|PL|VR"),
+	}, 0.5)
+	assert.Equal(1, len(animals.Kingdoms))
+	assert.Equal(1, len(plants.Kingdoms))
+
+	res := stats.CombineStats([]stats.WeightedStats{
+		{Stats: animals, Weight: 3},
+		{Stats: plants, Weight: 1},
+	})
+	assert.Equal(4, res.NamesNum)
+	assert.Equal(2, len(res.Kingdoms))
+	assert.Equal("Animalia", res.Kingdoms[0].Name)
+	assert.InDelta(0.75, res.Kingdoms[0].Percentage, 0.0001)
+	assert.Equal(2, res.Kingdoms[0].NamesNum)
+	assert.Equal("Plantae", res.Kingdoms[1].Name)
+	assert.InDelta(0.25, res.Kingdoms[1].Percentage, 0.0001)
+	assert.Equal("Animalia", res.Kingdom.Name)
+	assert.Equal("Strigidae", res.Family.Name)
+	assert.Equal("Strigidae", res.MainTaxon.Name)
+	assert.InDelta(0.75, res.MainTaxonPercentage, 0.0001)
+	assert.Equal(2, res.NamesOutsideMainTaxon)
+	assert.False(res.MainTaxonIsComplete)
+
+	res = stats.CombineStats([]stats.WeightedStats{
+		{Stats: animals, Weight: 1},
+		{Stats: plants, Weight: 1},
+	})
+	assert.Equal(2, len(res.Kingdoms))
+	assert.Equal("Animalia", res.Kingdom.Name)
+	assert.True(res.KingdomTie)
+	assert.Equal(2, len(res.CoDominant[stats.Kingdom]))
+	assert.Equal(res.Kingdom, res.CoDominant[stats.Kingdom][0])
+	assert.True(res.Phylum.IsZero())
+	assert.Equal(2, len(res.CoDominant[stats.Phylum]))
+	assert.True(res.MainTaxon.IsZero())
+
+	// Stats decoded from JSON do not have detailed counts.
+	var decoded stats.Stats
+	bs, err := json.Marshal(animals)
+	assert.Nil(err)
+	assert.Nil(json.Unmarshal(bs, &decoded))
+	res = stats.CombineStats([]stats.WeightedStats{
+		{Stats: decoded, Weight: 3},
+		{Stats: plants, Weight: 1},
+	})
+	assert.Equal("Animalia", res.Kingdom.Name)
+	assert.Equal("Strigidae", res.MainTaxon.Name)
+
+	res = stats.CombineStats(nil)
+	assert.Equal(stats.ReasonNoNames, res.EmptyReason)
+}
+
+func TestCombineStatsSourceWeights(t *testing.T) {
+	assert := assert.New(t)
+	opt := stats.OptSourceWeights(map[string]float32{"big": 0.2})
+	exp := stats.New(sourcedGroup(t), 0.5, opt)
+	assert.Equal("Amphisbaenidae", exp.MainTaxon.Name)
+
+	res := stats.CombineStats([]stats.WeightedStats{{Stats: exp, Weight: 1}})
+	assert.Equal(exp.MainTaxon, res.MainTaxon)
+	assert.InDelta(exp.MainTaxonPercentage, res.MainTaxonPercentage, 0.0001)
+	assert.Equal(exp.Phylum, res.Phylum)
+	assert.InDelta(exp.PhylumPercentage, res.PhylumPercentage, 0.0001)
+	assert.Equal(exp.NamesOutsideMainTaxon, res.NamesOutsideMainTaxon)
+}
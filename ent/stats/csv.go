@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrCSVFields is returned when a CSV row has less than three fields.
+var ErrCSVFields = errors.New("CSV row has less than 3 fields")
+
+// gzipMagic are the first bytes of gzip-compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ReadCSV reads hierarchies from CSV data. Every row contains
+// pipe-delimited names, ranks and IDs of a hierarchy in its first three
+// fields (see ParseHierarchy), other fields are ignored. Gzip-compressed
+// data are detected and decompressed on the fly. A row with less than two
+// taxons gives an empty hierarchy, the same as in verification results.
+func ReadCSV(r io.Reader) ([]Hierarchy, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(gzipMagic))
+	var src io.Reader = br
+	if string(magic) == string(gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read gzip data: %w", err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	cr := csv.NewReader(src)
+	cr.FieldsPerRecord = -1
+	var res []Hierarchy
+	for row := 1; ; row++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%w: row %d", ErrCSVFields, row)
+		}
+		h, err := ParseHierarchy(fields[0], fields[1], fields[2])
+		if errors.Is(err, ErrTooFewTaxons) {
+			h, err = hierarchy{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		res = append(res, h)
+	}
+	return res, nil
+}
+
+// ReadCSVFile reads hierarchies from a CSV file, for example
+// "reptiles.csv" or "reptiles.csv.gz". It uses ReadCSV, so gzipped
+// files are decompressed automatically.
+func ReadCSVFile(path string) ([]Hierarchy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCSV(f)
+}
@@ -0,0 +1,49 @@
+package stats_test
+
+import (
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCSVFile(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join("..", "..", "testdata", "reptiles.csv")
+	hs, err := stats.ReadCSVFile(path)
+	assert.Nil(err)
+	assert.Equal(len(taxons2(t, "reptiles.csv")), len(hs))
+	exp := stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	assert.True(exp.Equal(stats.New(hs, 0.5)))
+
+	data, err := os.ReadFile(path)
+	assert.Nil(err)
+	gzPath := filepath.Join(t.TempDir(), "reptiles.csv.gz")
+	f, err := os.Create(gzPath)
+	assert.Nil(err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write(data)
+	assert.Nil(err)
+	assert.Nil(gz.Close())
+	assert.Nil(f.Close())
+
+	hs, err = stats.ReadCSVFile(gzPath)
+	assert.Nil(err)
+	assert.True(exp.Equal(stats.New(hs, 0.5)))
+
+	_, err = stats.ReadCSV(strings.NewReader("Animalia|Chordata,kingdom|phylum\n"))
+	assert.True(errors.Is(err, stats.ErrCSVFields))
+	hs, err = stats.ReadCSV(strings.NewReader("Animalia,kingdom,N\n"))
+	assert.Nil(err)
+	assert.Equal(1, len(hs))
+	assert.Empty(hs[0].Taxons())
+	_, err = stats.ReadCSV(
+		strings.NewReader("Animalia|Chordata|Aves,kingdom|phylum,N|CH2|V2\n"),
+	)
+	assert.True(errors.Is(err, stats.ErrRanksMismatch))
+}
@@ -0,0 +1,187 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Distribution returns the distribution of names across taxons of the
+// given rank. It is sorted by percentage in descending order, and by
+// IDs and names for equal percentages. It returns nil if the rank had
+// no data.
+func (s Stats) Distribution(rank Rank) []TaxonDist {
+	counts, ok := s.rankCounts[rank]
+	if !ok || len(counts) == 0 {
+		return nil
+	}
+	res := appendTaxDist(nil, s.NamesNum, s.rankData(rank))
+	sortTaxDist(res)
+	if s.normalized && s.rankTotals[rank] == s.NamesNum {
+		normalizeDist(res)
+	}
+	return res
+}
+
+// OtherName is the name of the synthetic TaxonDist returned by
+// TopTaxaWithOther that aggregates the remaining taxons.
+const OtherName = "Other"
+
+// TopTaxaWithOther returns the n most prevalent taxons of the given rank
+// (see Distribution), followed by a synthetic TaxonDist named OtherName
+// that aggregates names of all remaining taxons. The Other entry is omitted
+// if there are no remaining taxons.
+func (s Stats) TopTaxaWithOther(rank Rank, n int) []TaxonDist {
+	dist := s.Distribution(rank)
+	if n < 0 {
+		n = 0
+	}
+	if len(dist) <= n {
+		return dist
+	}
+	other := TaxonDist{Name: OtherName}
+	for _, v := range dist[n:] {
+		other.NamesNum += v.NamesNum
+		other.CoverageShare += v.CoverageShare
+	}
+	other.Percentage = float32(other.NamesNum) / float32(s.NamesNum)
+	if _, ok := s.rankSourced[rank]; ok {
+		other.Percentage = 0
+		for _, v := range dist[n:] {
+			other.Percentage += v.Percentage
+		}
+	}
+	return append(dist[:n:n], other)
+}
+
+// FilterDist returns entries of a distribution that have a percentage of
+// at least minPct, keeping their order. It filters dist in place, so the
+// original slice should not be used afterwards.
+func FilterDist(dist []TaxonDist, minPct float32) []TaxonDist {
+	res := dist[:0]
+	for _, v := range dist {
+		if v.Percentage >= minPct {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// FilterDistWithOther works like FilterDist, but folds the removed entries
+// into a synthetic TaxonDist named OtherName at the end of the result.
+// The Other entry is omitted if no entries were removed.
+func FilterDistWithOther(dist []TaxonDist, minPct float32) []TaxonDist {
+	res := dist[:0]
+	other := TaxonDist{Name: OtherName}
+	var folded bool
+	for _, v := range dist {
+		if v.Percentage >= minPct {
+			res = append(res, v)
+			continue
+		}
+		folded = true
+		other.NamesNum += v.NamesNum
+		other.Percentage += v.Percentage
+		other.CoverageShare += v.CoverageShare
+	}
+	if folded {
+		res = append(res, other)
+	}
+	return res
+}
+
+// MainTaxonLadder returns the top taxons of genus, family, order, class,
+// phylum and kingdom, in this order, with their percentages. It shows how
+// the share of names grows when going up the hierarchy. The top taxon is
+// the first element of the rank's Distribution, so unlike Prevalent it is
+// returned also if several taxons share the highest percentage. The
+// ladder always has six elements, ranks without data have a zero
+// TaxonDist.
+func (s Stats) MainTaxonLadder() []TaxonDist {
+	res := make([]TaxonDist, len(majorRanks))
+	for i := range majorRanks {
+		r := majorRanks[len(majorRanks)-1-i]
+		if dist := s.Distribution(r); len(dist) > 0 {
+			res[i] = dist[0]
+		}
+	}
+	return res
+}
+
+// FractionUnder returns the fraction of names that have a taxon with
+// the given ID at any rank. It shows how many names belong to an expected
+// taxon, independently from the MainTaxon.
+func (s Stats) FractionUnder(taxonID string) float32 {
+	if s.NamesNum == 0 || taxonID == "" {
+		return 0
+	}
+	var num int
+	for _, counts := range s.rankCounts {
+		for k, v := range counts {
+			if k.ID == taxonID {
+				num += v
+			}
+		}
+	}
+	return float32(num) / float32(s.NamesNum)
+}
+
+// Singletons returns taxons of the given rank that contain only one name.
+// Such taxons often point to rare taxa or misidentifications.
+func (s Stats) Singletons(rank Rank) []TaxonDist {
+	var res []TaxonDist
+	for _, v := range s.Distribution(rank) {
+		if v.NamesNum == 1 {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// DefaultBands are lower limits of percentages for bands returned by
+// TaxonDist.Band: "dominant" (0.5 or more), "common" (0.1 or more)
+// and "rare" (less than 0.1).
+var DefaultBands = []float32{0.5, 0.1}
+
+var bandNames = []string{"dominant", "common", "rare"}
+
+// Band returns a qualitative band of the taxon percentage according to
+// DefaultBands.
+func (d TaxonDist) Band() string {
+	return bandNames[d.Bucketize(DefaultBands)]
+}
+
+// Bucketize returns the index of the first threshold that is not
+// greater than the percentage of the taxon. Thresholds have to be sorted
+// in descending order. If the percentage is lower than all thresholds,
+// the length of thresholds is returned.
+func (d TaxonDist) Bucketize(thresholds []float32) int {
+	for i, v := range thresholds {
+		if d.Percentage >= v {
+			return i
+		}
+	}
+	return len(thresholds)
+}
+
+// CountQuantile returns the q-quantile (0 <= q <= 1) of the numbers of
+// names of taxons at the given rank, using the nearest-rank method. For
+// example q=0.5 returns the median number of names per taxon, q=1 returns
+// the number of names in the largest taxon. Values of q outside of [0,1]
+// are clamped. It returns 0 if the rank had no data.
+func (s Stats) CountQuantile(rank Rank, q float64) int {
+	counts := s.rankCounts[rank]
+	if len(counts) == 0 {
+		return 0
+	}
+	nums := make([]int, 0, len(counts))
+	for _, v := range counts {
+		nums = append(nums, v)
+	}
+	sort.Ints(nums)
+	q = math.Max(0, math.Min(1, q))
+	idx := int(math.Ceil(q*float64(len(nums)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return nums[idx]
+}
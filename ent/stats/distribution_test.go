@@ -0,0 +1,223 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistribution(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	dist := res.Distribution(stats.Class)
+	assert.Greater(len(dist), 1)
+	assert.Equal("Gastropoda", dist[0].Name)
+	assert.Equal(38, dist[0].NamesNum)
+	assert.Equal(res.ClassPercentage, dist[0].Percentage)
+	for i := 1; i < len(dist); i++ {
+		assert.GreaterOrEqual(dist[i-1].Percentage, dist[i].Percentage)
+	}
+	assert.Nil(res.Distribution(stats.SuperKingdom))
+}
+
+func TestCoverageShare(t *testing.T) {
+	assert := assert.New(t)
+	hs := []stats.Hierarchy{
+		newHry(
+			"Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo|Bubo bubo",
+			"kingdom|phylum|class|order|family|genus|species",
+			"N|CH2|V2|466|GQX|3DQQ|NKSD",
+		),
+		newHry(
+			"Animalia|Chordata|Aves|Strigiformes|Strigidae|Strix|Strix aluco",
+			"kingdom|phylum|class|order|family|genus|species",
+			"N|CH2|V2|466|GQX|3DR5|4M8Q",
+		),
+		newHry(
+			"Animalia|Chordata|Aves|Passeriformes|Corvus|Corvus corax",
+			"kingdom|phylum|class|order|genus|species",
+			"N|CH2|V2|6KP|3C9X|5G7R",
+		),
+		newHry(
+			"Animalia|Chordata|Aves|Passeriformes|Parus|Parus major",
+			"kingdom|phylum|class|order|genus|species",
+			"N|CH2|V2|6KP|3DYS|4NX4",
+		),
+	}
+	res := stats.New(hs, 0.5)
+	dist := res.Distribution(stats.Family)
+	assert.Equal(1, len(dist))
+	assert.Equal("Strigidae", dist[0].Name)
+	assert.Equal(float32(0.5), dist[0].Percentage)
+	assert.Equal(float32(1), dist[0].CoverageShare)
+
+	dist = res.Distribution(stats.Order)
+	assert.Equal(2, len(dist))
+	for _, v := range dist {
+		assert.Equal(v.Percentage, v.CoverageShare)
+	}
+}
+
+func TestTopTaxaWithOther(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	dist := res.Distribution(stats.Family)
+	assert.Greater(len(dist), 5)
+	var total int
+	for _, v := range dist {
+		total += v.NamesNum
+	}
+
+	top := res.TopTaxaWithOther(stats.Family, 5)
+	assert.Equal(6, len(top))
+	assert.Equal(dist[:5], top[:5])
+	other := top[5]
+	assert.Equal(stats.OtherName, other.Name)
+	assert.Equal(total-(top[0].NamesNum+top[1].NamesNum+top[2].NamesNum+
+		top[3].NamesNum+top[4].NamesNum), other.NamesNum)
+	assert.InDelta(float32(other.NamesNum)/float32(res.NamesNum),
+		other.Percentage, 0.00001)
+
+	assert.Equal(dist, res.TopTaxaWithOther(stats.Family, len(dist)))
+	top = res.TopTaxaWithOther(stats.Family, 0)
+	assert.Equal(1, len(top))
+	assert.Equal(total, top[0].NamesNum)
+	assert.Nil(res.TopTaxaWithOther(stats.SuperKingdom, 3))
+}
+
+func TestFilterDist(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	exp := []string{
+		"Dactyloidae", "Phrynosomatidae", "Teiidae", "Gymnophthalmidae",
+		"Amphisbaenidae", "Tropiduridae",
+	}
+
+	dist := stats.FilterDist(res.Distribution(stats.Family), 0.05)
+	names := make([]string, len(dist))
+	for i, v := range dist {
+		names[i] = v.Name
+	}
+	assert.Equal(exp, names)
+
+	dist = stats.FilterDistWithOther(res.Distribution(stats.Family), 0.05)
+	assert.Equal(len(exp)+1, len(dist))
+	var total int
+	var pcent float32
+	for _, v := range dist {
+		total += v.NamesNum
+		pcent += v.Percentage
+	}
+	assert.Equal(stats.OtherName, dist[len(exp)].Name)
+	assert.Equal(res.NamesNum, total)
+	assert.InDelta(1, pcent, 0.0001)
+
+	dist = stats.FilterDistWithOther(res.Distribution(stats.Family), 0)
+	assert.Equal(res.Distribution(stats.Family), dist)
+	assert.Empty(stats.FilterDist(nil, 0.05))
+}
+
+func TestMainTaxonLadder(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	ladder := res.MainTaxonLadder()
+	assert.Equal(6, len(ladder))
+	names := make([]string, len(ladder))
+	for i, v := range ladder {
+		names[i] = v.Name
+		if i > 0 {
+			assert.GreaterOrEqual(v.Percentage, ladder[i-1].Percentage)
+		}
+	}
+	assert.Equal("Muricidae", names[1])
+	assert.Equal(
+		[]string{"Gastropoda", "Mollusca", "Animalia"},
+		names[3:],
+	)
+	assert.Equal(res.ClassPercentage, ladder[3].Percentage)
+	assert.Equal(float32(1), ladder[5].Percentage)
+	assert.Greater(ladder[5].Percentage, ladder[0].Percentage)
+
+	assert.Equal(make([]stats.TaxonDist, 6), stats.Stats{}.MainTaxonLadder())
+}
+
+func TestFractionUnder(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	assert.Equal("Squamata", res.MainTaxon.Name)
+	squamata := res.FractionUnder(res.MainTaxon.ID)
+	assert.InDelta(0.92, squamata, 0.01)
+	assert.Equal(res.MainTaxonPercentage, squamata)
+	assert.Equal(res.KingdomPercentage, res.FractionUnder(res.Kingdom.ID))
+	assert.Equal(float32(0), res.FractionUnder("unknown-id"))
+	assert.Equal(float32(0), res.FractionUnder(""))
+}
+
+func TestSingletons(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	families := make(map[string]int)
+	for i := range hs {
+		var qualified bool
+		var names []string
+		for _, v := range hs[i].Taxons() {
+			v = v.WithResolvedRank()
+			if v.Rank.Between(stats.SubSpecies, stats.Genus) {
+				qualified = true
+			}
+			if v.Rank == stats.Family {
+				names = append(names, v.Name)
+			}
+		}
+		if qualified {
+			for _, v := range names {
+				families[v]++
+			}
+		}
+	}
+	var exp int
+	for _, v := range families {
+		if v == 1 {
+			exp++
+		}
+	}
+
+	res := stats.New(hs, 0.5)
+	sg := res.Singletons(stats.Family)
+	assert.Greater(exp, 0)
+	assert.Equal(exp, len(sg))
+	for _, v := range sg {
+		assert.Equal(1, v.NamesNum)
+		assert.Equal(1, families[v.Name])
+	}
+	assert.Empty(res.Singletons(stats.SuperKingdom))
+}
+
+func TestBand(t *testing.T) {
+	assert := assert.New(t)
+	family := stats.TaxonDist{Name: "Muricidae", Percentage: 0.072}
+	class := stats.TaxonDist{Name: "Gastropoda", Percentage: 0.55}
+	assert.Equal("rare", family.Band())
+	assert.Equal("dominant", class.Band())
+	assert.Equal("common", stats.TaxonDist{Percentage: 0.1}.Band())
+
+	bands := []float32{0.9, 0.5, 0.05}
+	assert.Equal(2, family.Bucketize(bands))
+	assert.Equal(1, class.Bucketize(bands))
+	assert.Equal(3, stats.TaxonDist{Percentage: 0.01}.Bucketize(bands))
+	assert.Equal(0, class.Bucketize(nil))
+}
+
+func TestCountQuantile(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	dist := res.Distribution(stats.Family)
+	assert.Equal(66, len(dist))
+	assert.Equal(2, res.CountQuantile(stats.Family, 0.5))
+	assert.Equal(29, res.CountQuantile(stats.Family, 0.9))
+	assert.Equal(dist[0].NamesNum, res.CountQuantile(stats.Family, 1))
+	assert.Equal(1, res.CountQuantile(stats.Family, 0))
+	assert.Equal(1, res.CountQuantile(stats.Family, -1))
+	assert.Equal(0, res.CountQuantile(stats.SuperKingdom, 0.5))
+}
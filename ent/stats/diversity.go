@@ -0,0 +1,257 @@
+package stats
+
+import (
+	"maps"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Entropies returns Shannon entropy (natural logarithm) of names
+// distribution for every rank that had data during the calculation of
+// stats. Entropy of 0 means that all names at a rank belong to the same
+// taxon. If hierarchies implement WeightedHierarchy, proportions of taxons
+// are calculated from weights of names, otherwise every name has weight 1,
+// which gives the standard index.
+func (s Stats) Entropies() map[Rank]float64 {
+	return maps.Clone(s.entropies)
+}
+
+// ShannonIndex returns Shannon diversity index (entropy) of the given rank.
+// It returns 0 for ranks without data.
+func (s Stats) ShannonIndex(rank Rank) float64 {
+	return s.entropies[rank]
+}
+
+// SimpsonIndex returns Simpson diversity index (1 - sum of squared
+// proportions) of the given rank. Proportions are calculated the same way
+// as for ShannonIndex. It returns 0 for ranks without data.
+func (s Stats) SimpsonIndex(rank Rank) float64 {
+	return s.simpsons[rank]
+}
+
+// MostDiverseRank returns the rank with the highest Shannon entropy (see
+// Entropies), the rank where names are spread most. If several ranks have
+// the same entropy, the highest of them is returned. Empty and Unknown
+// ranks are ignored. It returns Empty if there are no ranks with
+// a positive entropy.
+func (s Stats) MostDiverseRank() Rank {
+	res := Empty
+	var max float64
+	for _, r := range Ranks() {
+		if r.AtMost(Unknown) {
+			continue
+		}
+		if v := s.entropies[r]; v > max {
+			res, max = r, v
+		}
+	}
+	return res
+}
+
+// RankWeightedDiversity approximates phylogenetic diversity of names using
+// only their ranks. It is the average taxonomic distinctness: the mean
+// distance between all pairs of names, where the distance is the number of
+// steps along the ladder of main ranks from species up to the lowest
+// common taxon of the pair. Names of the same species have distance 0, of
+// the same genus 1, of the same family 2, of the same order 3, of the same
+// class 4, of the same phylum 5, of the same kingdom 6, and names without a
+// common kingdom have distance 7. Ranks missing in one of the names are
+// skipped. The number of compared pairs grows quadratically with the
+// number of names. It returns 0 if there are less than two names.
+func (s Stats) RankWeightedDiversity() float64 {
+	if len(s.members) < 2 {
+		return 0
+	}
+	const levels = 7
+	paths := make([][levels]Taxon, len(s.members))
+	for i, m := range s.members {
+		for _, v := range m.taxons {
+			if v.Rank == Species || isMajorRank(v.Rank) {
+				paths[i][v.Rank.Depth()] = v
+			}
+		}
+	}
+
+	var sum float64
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			dist := levels
+			for l := levels - 1; l >= 0; l-- {
+				t1, t2 := paths[i][l], paths[j][l]
+				if !t1.IsZero() && t1 == t2 {
+					dist = levels - 1 - l
+					break
+				}
+			}
+			sum += float64(dist)
+		}
+	}
+	pairs := len(paths) * (len(paths) - 1) / 2
+	return sum / float64(pairs)
+}
+
+// calcDiversity calculates Shannon entropy and Simpson index for all given
+// ranks in one pass and saves them to the entropies and simpsons maps of
+// res. Proportions are calculated relative to the total weight of names
+// that reached the rank.
+func calcDiversity(res *Stats, ranks []rankData) {
+	if res.entropies == nil {
+		res.entropies = make(map[Rank]float64, len(ranks))
+	}
+	if res.simpsons == nil {
+		res.simpsons = make(map[Rank]float64, len(ranks))
+	}
+	for i := range ranks {
+		shannon, simpson := diversity(ranks[i])
+		res.entropies[ranks[i].rank] = shannon
+		res.simpsons[ranks[i].rank] = simpson
+	}
+}
+
+func diversity(rd rankData) (float64, float64) {
+	weights, total := rd.weighted()
+	if total <= 0 {
+		return 0, 0
+	}
+	var shannon, sumSq float64
+	for _, v := range weights {
+		// guard against log(0)
+		if v <= 0 {
+			continue
+		}
+		p := v / total
+		shannon -= p * math.Log(p)
+		sumSq += p * p
+	}
+	// avoid negative zero
+	if shannon == 0 {
+		shannon = 0
+	}
+	return shannon, 1 - sumSq
+}
+
+// DiversityMetric calculates a diversity index from a distribution of names
+// across taxons of one rank.
+type DiversityMetric interface {
+	// Compute returns the value of the index for the distribution.
+	Compute(dist []TaxonDist) float64
+}
+
+// ShannonMetric calculates Shannon entropy (natural logarithm) from the
+// numbers of names of taxons.
+type ShannonMetric struct{}
+
+// Compute implements DiversityMetric.
+func (ShannonMetric) Compute(dist []TaxonDist) float64 {
+	total := distNamesNum(dist)
+	if total == 0 {
+		return 0
+	}
+	var res float64
+	for _, v := range dist {
+		if v.NamesNum <= 0 {
+			continue
+		}
+		p := float64(v.NamesNum) / float64(total)
+		res -= p * math.Log(p)
+	}
+	// avoid negative zero
+	if res == 0 {
+		res = 0
+	}
+	return res
+}
+
+// SimpsonMetric calculates Simpson diversity index (1 - sum of squared
+// proportions) from the numbers of names of taxons.
+type SimpsonMetric struct{}
+
+// Compute implements DiversityMetric.
+func (SimpsonMetric) Compute(dist []TaxonDist) float64 {
+	total := distNamesNum(dist)
+	if total == 0 {
+		return 0
+	}
+	var sumSq float64
+	for _, v := range dist {
+		if v.NamesNum <= 0 {
+			continue
+		}
+		p := float64(v.NamesNum) / float64(total)
+		sumSq += p * p
+	}
+	return 1 - sumSq
+}
+
+func distNamesNum(dist []TaxonDist) int {
+	var res int
+	for _, v := range dist {
+		if v.NamesNum > 0 {
+			res += v.NamesNum
+		}
+	}
+	return res
+}
+
+// DiversityMetrics is a registry of metrics used by Stats.Diversity. It
+// contains "shannon" and "simpson" metrics by default.
+var DiversityMetrics = func() *DiversityMetricRegistry {
+	res := NewDiversityMetricRegistry()
+	res.Register("shannon", ShannonMetric{})
+	res.Register("simpson", SimpsonMetric{})
+	return res
+}()
+
+// DiversityMetricRegistry keeps diversity metrics by their names. It is
+// safe for concurrent use.
+type DiversityMetricRegistry struct {
+	mu      sync.RWMutex
+	metrics map[string]DiversityMetric
+}
+
+// NewDiversityMetricRegistry creates an empty DiversityMetricRegistry.
+func NewDiversityMetricRegistry() *DiversityMetricRegistry {
+	return &DiversityMetricRegistry{metrics: make(map[string]DiversityMetric)}
+}
+
+// Register adds a metric with the given name, replacing a metric that was
+// registered with the same name before. Names are case-insensitive.
+func (r *DiversityMetricRegistry) Register(name string, m DiversityMetric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[strings.ToLower(name)] = m
+}
+
+// Unregister removes a metric with the given name. Names are
+// case-insensitive.
+func (r *DiversityMetricRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.metrics, strings.ToLower(name))
+}
+
+// Lookup returns a metric by its name and true if the metric is registered.
+func (r *DiversityMetricRegistry) Lookup(name string) (DiversityMetric, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.metrics[strings.ToLower(name)]
+	return m, ok
+}
+
+// Diversity calculates the value of a metric registered in
+// DiversityMetrics for the distribution of the given rank (see
+// Distribution). Unlike ShannonIndex and SimpsonIndex, it uses numbers of
+// names and ignores weights of hierarchies. It returns 0 if the metric is
+// not registered or if the rank had no data.
+func (s Stats) Diversity(rank Rank, metric string) float64 {
+	m, ok := DiversityMetrics.Lookup(metric)
+	if !ok {
+		return 0
+	}
+	dist := s.Distribution(rank)
+	if len(dist) == 0 {
+		return 0
+	}
+	return m.Compute(dist)
+}
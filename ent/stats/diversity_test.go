@@ -0,0 +1,125 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntropies(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res := stats.New(hs, 0.5)
+	ent := res.Entropies()
+	assert.Equal(float64(0), ent[stats.Kingdom])
+	assert.Equal(float64(0), ent[stats.Phylum])
+	assert.Greater(ent[stats.Class], float64(0))
+	assert.Greater(ent[stats.Family], ent[stats.Class])
+	_, ok := ent[stats.SuperKingdom]
+	assert.False(ok)
+}
+
+func TestMostDiverseRank(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	// almost every mollusc name has its own genus
+	assert.Equal(stats.Genus, res.MostDiverseRank())
+	ent := res.Entropies()
+	for r, v := range ent {
+		assert.LessOrEqual(v, ent[stats.Genus], r.String())
+	}
+
+	hs := testData(t)
+	res = stats.New([]stats.Hierarchy{hs[0], hs[0]}, 0.5)
+	assert.Equal(stats.Empty, res.MostDiverseRank())
+	assert.Equal(stats.Empty, stats.Stats{}.MostDiverseRank())
+}
+
+type weighted struct {
+	stats.Hierarchy
+	weight float32
+}
+
+func (w weighted) Weight() float32 {
+	return w.weight
+}
+
+func TestWeightedDiversity(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus"
+	owl := newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo", ranks,
+		"N|CH2|V2|466|GQX|3DQQ")
+	crow := newHry("Animalia|Chordata|Aves|Passeriformes|Corvidae|Corvus", ranks,
+		"N|CH2|V2|H4|C8R|6DBK")
+
+	res := stats.New([]stats.Hierarchy{owl, crow}, 0.5)
+	assert.InDelta(math.Log(2), res.ShannonIndex(stats.Family), 1e-9)
+	assert.InDelta(0.5, res.SimpsonIndex(stats.Family), 1e-9)
+	assert.Equal(float64(0), res.ShannonIndex(stats.Class))
+
+	// weight 1 gives the standard index
+	hs := []stats.Hierarchy{weighted{owl, 1}, weighted{crow, 1}}
+	res = stats.New(hs, 0.5)
+	assert.InDelta(math.Log(2), res.ShannonIndex(stats.Family), 1e-9)
+
+	hs = []stats.Hierarchy{weighted{owl, 9}, weighted{crow, 1}}
+	res = stats.New(hs, 0.5)
+	exp := -(0.9*math.Log(0.9) + 0.1*math.Log(0.1))
+	assert.InDelta(exp, res.ShannonIndex(stats.Family), 1e-6)
+	assert.InDelta(1-(0.81+0.01), res.SimpsonIndex(stats.Family), 1e-6)
+	assert.Less(res.ShannonIndex(stats.Family), math.Log(2))
+	// weights do not change names counts
+	assert.Equal(2, res.NamesNum)
+	assert.Equal(float32(1), res.ResolutionRate(stats.Genus))
+}
+
+// richness is a diversity metric that returns the number of taxons.
+type richness struct{}
+
+func (richness) Compute(dist []stats.TaxonDist) float64 {
+	return float64(len(dist))
+}
+
+func TestDiversity(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	for _, r := range []stats.Rank{stats.Kingdom, stats.Class, stats.Family} {
+		assert.InDelta(res.ShannonIndex(r), res.Diversity(r, "shannon"), 1e-9)
+		assert.InDelta(res.SimpsonIndex(r), res.Diversity(r, "Simpson"), 1e-9)
+	}
+
+	assert.Equal(float64(0), res.Diversity(stats.Class, "richness"))
+	t.Cleanup(func() { stats.DiversityMetrics.Unregister("richness") })
+	stats.DiversityMetrics.Register("richness", richness{})
+	dist := res.Distribution(stats.Class)
+	assert.Equal(float64(len(dist)), res.Diversity(stats.Class, "richness"))
+	assert.Equal(float64(0), res.Diversity(stats.SuperKingdom, "richness"))
+
+	stats.DiversityMetrics.Unregister("Richness")
+	assert.Equal(float64(0), res.Diversity(stats.Class, "richness"))
+}
+
+func TestRankWeightedDiversity(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus|species"
+	owls := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo|Bubo bubo",
+			ranks, "N|CH2|V2|466|GQX|3DQQ|1"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo|Bubo scandiacus",
+			ranks, "N|CH2|V2|466|GQX|3DQQ|2"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Strix|Strix aluco",
+			ranks, "N|CH2|V2|466|GQX|6W7S|3"),
+	}
+	// pairs are at distances 1 (same genus), 2 and 2 (same family)
+	low := stats.New(owls, 0.5).RankWeightedDiversity()
+	assert.InDelta(5.0/3, low, 1e-9)
+
+	high := stats.New(fiftyFifty(), 0.5).RankWeightedDiversity()
+	assert.Greater(high, low)
+	assert.Greater(high, 5.0)
+	assert.LessOrEqual(high, 7.0)
+
+	assert.Equal(0.0, stats.New(owls[:1], 0.5).RankWeightedDiversity())
+}
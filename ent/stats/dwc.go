@@ -0,0 +1,66 @@
+package stats
+
+import "strings"
+
+// dwcRanks are Darwin Core terms for a classification with corresponding
+// ranks, ordered from the most general to the most specific.
+var dwcRanks = []struct {
+	term string
+	rank Rank
+}{
+	{"kingdom", Kingdom},
+	{"phylum", Phylum},
+	{"class", Class},
+	{"order", Order},
+	{"family", Family},
+	{"subfamily", SubFamily},
+	{"tribe", Tribe},
+	{"genus", Genus},
+	{"subgenus", SubGenus},
+}
+
+// FromDwC creates hierarchies from records that use Darwin Core
+// classification terms as keys: kingdom, phylum, class, order, family,
+// subfamily, tribe, genus, subgenus, specificEpithet and
+// infraspecificEpithet. Species and subspecies names are assembled from
+// the genus and the epithets. Missing or empty terms are skipped, making
+// a hierarchy shorter. Darwin Core records do not provide IDs for higher
+// taxa, so IDs of the taxons are empty.
+func FromDwC(records []map[string]string) []Hierarchy {
+	res := make([]Hierarchy, len(records))
+	for i, rec := range records {
+		var taxons []Taxon
+		for _, v := range dwcRanks {
+			name := strings.TrimSpace(rec[v.term])
+			if name == "" {
+				continue
+			}
+			taxons = append(taxons, Taxon{
+				Name:    name,
+				RankStr: v.rank.String(),
+				Rank:    v.rank,
+			})
+		}
+
+		genus := strings.TrimSpace(rec["genus"])
+		sp := strings.TrimSpace(rec["specificEpithet"])
+		if genus != "" && sp != "" {
+			name := genus + " " + sp
+			taxons = append(taxons, Taxon{
+				Name:    name,
+				RankStr: Species.String(),
+				Rank:    Species,
+			})
+			ssp := strings.TrimSpace(rec["infraspecificEpithet"])
+			if ssp != "" {
+				taxons = append(taxons, Taxon{
+					Name:    name + " " + ssp,
+					RankStr: SubSpecies.String(),
+					Rank:    SubSpecies,
+				})
+			}
+		}
+		res[i] = hierarchy{taxons: taxons}
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromDwC(t *testing.T) {
+	assert := assert.New(t)
+	birds := map[string]string{
+		"kingdom": "Animalia",
+		"phylum":  "Chordata",
+		"class":   "Aves",
+	}
+	rec := func(order, family, genus, sp string) map[string]string {
+		res := map[string]string{
+			"order":           order,
+			"family":          family,
+			"genus":           genus,
+			"specificEpithet": sp,
+		}
+		for k, v := range birds {
+			res[k] = v
+		}
+		return res
+	}
+	recs := []map[string]string{
+		rec("Strigiformes", "Strigidae", "Bubo", "bubo"),
+		rec("Strigiformes", "Strigidae", "Strix", "aluco"),
+		rec("Passeriformes", "Corvidae", "Corvus", "corax"),
+		{"kingdom": "Animalia", "phylum": "Chordata"},
+	}
+	recs[1]["infraspecificEpithet"] = "sylvatica"
+	hs := stats.FromDwC(recs)
+	assert.Equal(4, len(hs))
+
+	taxons := hs[1].Taxons()
+	assert.Equal(8, len(taxons))
+	assert.Equal("Strix aluco", taxons[6].Name)
+	assert.Equal(stats.Species, taxons[6].Rank)
+	assert.Equal("Strix aluco sylvatica", taxons[7].Name)
+	assert.Equal(stats.SubSpecies, taxons[7].Rank)
+	assert.Equal(2, len(hs[3].Taxons()))
+
+	res := stats.New(hs, 0.5)
+	assert.Equal(3, res.NamesNum)
+	assert.Equal("Animalia", res.Kingdom.Name)
+	assert.Equal("Strigidae", res.MainTaxon.Name)
+	assert.Equal(stats.Family, res.MainTaxon.Rank)
+	assert.InDelta(float32(0.67), res.MainTaxonPercentage, 0.01)
+}
@@ -0,0 +1,26 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// EncodeBinary encodes Stats into a compact binary form using
+// encoding/gob. It is faster and smaller than JSON and is intended for
+// caching of Stats. The same as with JSON, only exported fields are
+// encoded, so accessors that depend on detailed counts (for example
+// Distribution or ShannonIndex) return empty results after decoding.
+func EncodeBinary(s Stats) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBinary decodes Stats from data created by EncodeBinary.
+func DecodeBinary(data []byte) (Stats, error) {
+	var res Stats
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&res)
+	return res, err
+}
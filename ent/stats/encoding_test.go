@@ -0,0 +1,56 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeBinary(t *testing.T) {
+	assert := assert.New(t)
+	s := stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	bs, err := stats.EncodeBinary(s)
+	assert.Nil(err)
+
+	res, err := stats.DecodeBinary(bs)
+	assert.Nil(err)
+	assert.Equal(s.Kingdoms, res.Kingdoms)
+	assert.Equal(s.Kingdom, res.Kingdom)
+	assert.Equal(stats.Kingdom, res.Kingdom.Rank)
+	assert.Equal(s.MainTaxon, res.MainTaxon)
+	assert.Equal(s.MainTaxonPercentage, res.MainTaxonPercentage)
+	assert.Equal(s.Genera, res.Genera)
+
+	js, err := json.Marshal(s)
+	assert.Nil(err)
+	var fromJSON stats.Stats
+	assert.Nil(json.Unmarshal(js, &fromJSON))
+	assert.True(fromJSON.Equal(res))
+	assert.Less(len(bs), len(js))
+
+	_, err = stats.DecodeBinary([]byte("not gob"))
+	assert.NotNil(err)
+}
+
+func BenchmarkEncodeBinary(b *testing.B) {
+	s := stats.New(taxons2(b, "reptiles.csv"), 0.5)
+	b.Run("gob", func(b *testing.B) {
+		var bs []byte
+		for i := 0; i < b.N; i++ {
+			bs, _ = stats.EncodeBinary(s)
+			_, _ = stats.DecodeBinary(bs)
+		}
+		b.ReportMetric(float64(len(bs)), "encoded-bytes")
+	})
+	b.Run("json", func(b *testing.B) {
+		var bs []byte
+		for i := 0; i < b.N; i++ {
+			var res stats.Stats
+			bs, _ = json.Marshal(s)
+			_ = json.Unmarshal(bs, &res)
+		}
+		b.ReportMetric(float64(len(bs)), "encoded-bytes")
+	})
+}
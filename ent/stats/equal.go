@@ -0,0 +1,169 @@
+package stats
+
+import "math"
+
+// floatTolerance is the maximal difference between float values that are
+// considered equal.
+const floatTolerance = 1e-6
+
+// Equal compares two Stats. Float values are compared with a small
+// tolerance, and the order of Kingdoms and Genera is ignored.
+func (s Stats) Equal(other Stats) bool {
+	if s.NamesNum != other.NamesNum ||
+		s.InputCount != other.InputCount ||
+		s.DroppedNames != other.DroppedNames ||
+		s.Kingdom != other.Kingdom ||
+		s.KingdomTie != other.KingdomTie ||
+		s.Phylum != other.Phylum ||
+		s.Class != other.Class ||
+		s.Order != other.Order ||
+		s.Family != other.Family ||
+		s.Genus != other.Genus ||
+		s.Species != other.Species ||
+		s.MainTaxon != other.MainTaxon ||
+		s.MainTaxonIsComplete != other.MainTaxonIsComplete ||
+		s.NamesOutsideMainTaxon != other.NamesOutsideMainTaxon ||
+		s.EmptyReason != other.EmptyReason {
+		return false
+	}
+
+	pcents := [][2]float32{
+		{s.KingdomPercentage, other.KingdomPercentage},
+		{s.PhylumPercentage, other.PhylumPercentage},
+		{s.ClassPercentage, other.ClassPercentage},
+		{s.OrderPercentage, other.OrderPercentage},
+		{s.FamilyPercentage, other.FamilyPercentage},
+		{s.GenusPercentage, other.GenusPercentage},
+		{s.SpeciesPercentage, other.SpeciesPercentage},
+		{s.MainTaxonPercentage, other.MainTaxonPercentage},
+		{s.GenusResolutionRate, other.GenusResolutionRate},
+	}
+	for _, v := range pcents {
+		if !floatEqual(float64(v[0]), float64(v[1])) {
+			return false
+		}
+	}
+
+	if !taxDistEqual(s.Kingdoms, other.Kingdoms) {
+		return false
+	}
+	if !taxDistEqual(s.Genera, other.Genera) {
+		return false
+	}
+
+	if len(s.Warnings) != len(other.Warnings) {
+		return false
+	}
+	for i := range s.Warnings {
+		if s.Warnings[i] != other.Warnings[i] {
+			return false
+		}
+	}
+
+	if len(s.CoDominant) != len(other.CoDominant) {
+		return false
+	}
+	for k, v := range s.CoDominant {
+		v2, ok := other.CoDominant[k]
+		if !ok || len(v) != len(v2) {
+			return false
+		}
+		for i := range v {
+			if v[i] != v2[i] {
+				return false
+			}
+		}
+	}
+
+	if !floatMapEqual(s.entropies, other.entropies) ||
+		!floatMapEqual(s.simpsons, other.simpsons) {
+		return false
+	}
+
+	if len(s.topPercentages) != len(other.topPercentages) {
+		return false
+	}
+	for k, v := range s.topPercentages {
+		v2, ok := other.topPercentages[k]
+		if !ok || !floatEqual(float64(v), float64(v2)) {
+			return false
+		}
+	}
+
+	if len(s.rankTotals) != len(other.rankTotals) {
+		return false
+	}
+	for k, v := range s.rankTotals {
+		if v2, ok := other.rankTotals[k]; !ok || v != v2 {
+			return false
+		}
+	}
+
+	if !nestedCountsEqual(s.rankCounts, other.rankCounts) {
+		return false
+	}
+	return nestedCountsEqual(s.children, other.children)
+}
+
+func nestedCountsEqual[K comparable](m1, m2 map[K]map[Taxon]int) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+	for k, v := range m1 {
+		v2, ok := m2[k]
+		if !ok || len(v) != len(v2) {
+			return false
+		}
+		for kk, vv := range v {
+			if vv2, ok := v2[kk]; !ok || vv != vv2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// taxDistEqual compares two distributions ignoring their order.
+func taxDistEqual(td1, td2 []TaxonDist) bool {
+	if len(td1) != len(td2) {
+		return false
+	}
+	if len(td1) == 0 {
+		return true
+	}
+
+	c1 := make([]TaxonDist, len(td1))
+	c2 := make([]TaxonDist, len(td2))
+	copy(c1, td1)
+	copy(c2, td2)
+	sortTaxDist(c1)
+	sortTaxDist(c2)
+	for i := range c1 {
+		if c1[i].ID != c2[i].ID ||
+			c1[i].Name != c2[i].Name ||
+			c1[i].NamesNum != c2[i].NamesNum ||
+			!floatEqual(float64(c1[i].Percentage), float64(c2[i].Percentage)) ||
+			!floatEqual(float64(c1[i].CoverageShare),
+				float64(c2[i].CoverageShare)) {
+			return false
+		}
+	}
+	return true
+}
+
+func floatMapEqual(m1, m2 map[Rank]float64) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+	for k, v := range m1 {
+		v2, ok := m2[k]
+		if !ok || !floatEqual(v, v2) {
+			return false
+		}
+	}
+	return true
+}
+
+func floatEqual(f1, f2 float64) bool {
+	return math.Abs(f1-f2) <= floatTolerance
+}
@@ -0,0 +1,43 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	res1 := stats.New(hs, 0.5)
+	assert.Greater(len(res1.Kingdoms), 1)
+	for i := 0; i < 10; i++ {
+		res2 := stats.New(hs, 0.5)
+		assert.True(res1.Equal(res2))
+	}
+
+	res2 := stats.New(hs, 0.5)
+	kd := res2.Kingdoms
+	kd[0], kd[len(kd)-1] = kd[len(kd)-1], kd[0]
+	assert.True(res1.Equal(res2))
+
+	res2.KingdomPercentage += 1e-8
+	assert.True(res1.Equal(res2))
+
+	res2.KingdomPercentage += 0.01
+	assert.False(res1.Equal(res2))
+
+	res2 = stats.New(hs, 0.5)
+	res2.Kingdoms = res2.Kingdoms[1:]
+	assert.False(res1.Equal(res2))
+
+	res2 = stats.New(hs, 0.5)
+	res2.Kingdoms[0].CoverageShare += 0.01
+	assert.False(res1.Equal(res2))
+
+	res2 = stats.New(hs[1:], 0.5)
+	assert.False(res1.Equal(res2))
+
+	assert.True(stats.Stats{}.Equal(stats.Stats{}))
+}
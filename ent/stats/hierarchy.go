@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrTooFewTaxons is returned when a classification path contains less
+	// than two taxons.
+	ErrTooFewTaxons = errors.New("classification has less than two taxons")
+
+	// ErrRanksMismatch is returned when there are less ranks than taxons.
+	ErrRanksMismatch = errors.New("there are less ranks than taxons")
+
+	// ErrIDsMismatch is returned when there are less IDs than taxons.
+	ErrIDsMismatch = errors.New("there are less IDs than taxons")
+
+	// ErrInconsistentTree is returned when a hierarchy contains more than
+	// one taxon of the same rank.
+	ErrInconsistentTree = errors.New("hierarchy contains repeated ranks")
+)
+
+// hierarchy is a simple implementation of the Hierarchy interface.
+type hierarchy struct {
+	taxons []Taxon
+}
+
+// Taxons returns taxons of the hierarchy.
+func (h hierarchy) Taxons() []Taxon {
+	return h.taxons
+}
+
+// ParseHierarchy creates a Hierarchy from pipe-delimited strings of taxon
+// names, ranks and IDs, for example:
+//
+//	"Biota|Animalia|Chordata", "unranked|kingdom|phylum", "5T6MX|N|CH2"
+//
+// Names have to be ordered from the most general to the most specific taxon.
+// It returns an error if there are less than two names, or if there are
+// less ranks or IDs than names.
+func ParseHierarchy(names, ranks, ids string) (Hierarchy, error) {
+	namesSl := strings.Split(names, "|")
+	if len(namesSl) < 2 {
+		return hierarchy{}, fmt.Errorf("%w: '%s'", ErrTooFewTaxons, names)
+	}
+
+	ranksSl := strings.Split(ranks, "|")
+	if len(namesSl) > len(ranksSl) {
+		return hierarchy{}, fmt.Errorf(
+			"%w: %d taxons, %d ranks", ErrRanksMismatch, len(namesSl), len(ranksSl),
+		)
+	}
+
+	idsSl := strings.Split(ids, "|")
+	if len(namesSl) > len(idsSl) {
+		return hierarchy{}, fmt.Errorf(
+			"%w: %d taxons, %d IDs", ErrIDsMismatch, len(namesSl), len(idsSl),
+		)
+	}
+
+	taxons := make([]Taxon, len(namesSl))
+	for i := range namesSl {
+		taxons[i] = Taxon{
+			Name:    namesSl[i],
+			RankStr: ranksSl[i],
+			ID:      idsSl[i],
+		}
+	}
+	return hierarchy{taxons: taxons}, nil
+}
+
+// Align arranges taxons of hierarchies into a matrix with a column for
+// every rank. It returns ranks found in the hierarchies ordered from
+// the highest to the lowest, and for every hierarchy a row of taxons that
+// correspond to these ranks. If a hierarchy does not have a rank, the row
+// contains a zero Taxon for it. Taxons with Empty or Unknown ranks are
+// ignored. If a hierarchy has several taxons of the same rank, the first
+// one is used.
+func Align(h []Hierarchy) ([]Rank, [][]Taxon) {
+	present := make(map[Rank]struct{})
+	resolved := make([][]Taxon, len(h))
+	for i := range h {
+		hTaxons := h[i].Taxons()
+		taxons := make([]Taxon, len(hTaxons))
+		for ii := range hTaxons {
+			taxons[ii] = hTaxons[ii].WithResolvedRank()
+			if taxons[ii].Rank.AtMost(Unknown) {
+				continue
+			}
+			present[taxons[ii].Rank] = struct{}{}
+		}
+		resolved[i] = taxons
+	}
+
+	var ranks []Rank
+	cols := make(map[Rank]int, len(present))
+	for _, r := range Ranks() {
+		if _, ok := present[r]; ok {
+			cols[r] = len(ranks)
+			ranks = append(ranks, r)
+		}
+	}
+
+	rows := make([][]Taxon, len(h))
+	for i, taxons := range resolved {
+		row := make([]Taxon, len(ranks))
+		for _, v := range taxons {
+			col, ok := cols[v.Rank]
+			if !ok || !row[col].IsZero() {
+				continue
+			}
+			row[col] = v
+		}
+		rows[i] = row
+	}
+	return ranks, rows
+}
+
+// Fingerprint returns a deterministic hash of hierarchies that does not
+// depend on their order. It can be used as a key for caching of Stats.
+//
+// The algorithm: every taxon is serialized as its ID, Name and RankStr
+// joined by the unit separator (0x1F); taxons of a hierarchy are joined by
+// the record separator (0x1E) in their original order. Serialized
+// hierarchies are sorted as byte strings and joined by the newline (0x0A).
+// The result is a lowercase hexadecimal SHA-256 hash of the UTF-8 bytes
+// of the joined string.
+func Fingerprint(h []Hierarchy) string {
+	hs := make([]string, len(h))
+	for i := range h {
+		taxons := h[i].Taxons()
+		ts := make([]string, len(taxons))
+		for ii, v := range taxons {
+			ts[ii] = v.ID + "\x1f" + v.Name + "\x1f" + v.RankStr
+		}
+		hs[i] = strings.Join(ts, "\x1e")
+	}
+	sort.Strings(hs)
+	sum := sha256.Sum256([]byte(strings.Join(hs, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithoutTaxon returns hierarchies that do not contain a taxon with the
+// given name at any rank. It does not modify the input slice, so the result
+// can be used to recalculate Stats without an outlier taxon.
+func WithoutTaxon(h []Hierarchy, name string) []Hierarchy {
+	res := make([]Hierarchy, 0, len(h))
+	for i := range h {
+		if !hasTaxon(h[i], name) {
+			res = append(res, h[i])
+		}
+	}
+	return res
+}
+
+func hasTaxon(h Hierarchy, name string) bool {
+	for _, v := range h.Taxons() {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTree returns ErrInconsistentTree if taxons contain more than one
+// taxon of the same rank. Taxons of Empty and Unknown ranks are ignored.
+func checkTree(taxons []Taxon) error {
+	seen := make(map[Rank]string, len(taxons))
+	for _, v := range taxons {
+		if v.Rank.AtMost(Unknown) {
+			continue
+		}
+		if name, ok := seen[v.Rank]; ok {
+			return fmt.Errorf(
+				"%w: %s '%s' and '%s'", ErrInconsistentTree, v.Rank, name, v.Name,
+			)
+		}
+		seen[v.Rank] = v.Name
+	}
+	return nil
+}
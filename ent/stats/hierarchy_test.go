@@ -0,0 +1,113 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHierarchy(t *testing.T) {
+	assert := assert.New(t)
+	h, err := stats.ParseHierarchy(
+		"Biota|Animalia|Chordata|Aves|Strigiformes|Strigidae|Striginae|Bubo|Bubo bubo",
+		"unranked|kingdom|phylum|class|order|family|subfamily|genus|species",
+		"5T6MX|N|CH2|V2|466|GQX|KDK|3DQQ|NKSD",
+	)
+	assert.Nil(err)
+	taxons := h.Taxons()
+	assert.Equal(9, len(taxons))
+	assert.Equal(stats.Taxon{Name: "Bubo", RankStr: "genus", ID: "3DQQ"}, taxons[7])
+}
+
+func TestParseHierarchyErr(t *testing.T) {
+	tests := []struct {
+		msg, names, ranks, ids string
+		err                    error
+	}{
+		{"one taxon", "Biota", "unranked", "5T6MX", stats.ErrTooFewTaxons},
+		{"empty", "", "", "", stats.ErrTooFewTaxons},
+		{
+			"ranks", "Biota|Animalia|Chordata", "unranked|kingdom", "5T6MX|N|CH2",
+			stats.ErrRanksMismatch,
+		},
+		{
+			"ids", "Biota|Animalia|Chordata", "unranked|kingdom|phylum", "5T6MX|N",
+			stats.ErrIDsMismatch,
+		},
+	}
+	for _, v := range tests {
+		h, err := stats.ParseHierarchy(v.names, v.ranks, v.ids)
+		assert.ErrorIs(t, err, v.err, v.msg)
+		assert.Empty(t, h.Taxons(), v.msg)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	assert := assert.New(t)
+	hs := fiftyFifty()
+	ranks, rows := stats.Align(hs)
+	assert.Equal([]stats.Rank{
+		stats.Kingdom, stats.Phylum, stats.Class, stats.SubClass,
+		stats.InfraClass, stats.Order, stats.SubOrder, stats.Family,
+		stats.SubFamily, stats.Genus, stats.Species,
+	}, ranks)
+	assert.Equal(len(hs), len(rows))
+
+	subclass := 3
+	var filled int
+	for i, row := range rows {
+		assert.Equal(len(ranks), len(row))
+		// every name has a kingdom
+		assert.False(row[0].IsZero())
+		if !row[subclass].IsZero() {
+			filled++
+			assert.Equal(1, i)
+			assert.Equal("Theria", row[subclass].Name)
+		}
+	}
+	assert.Equal(1, filled)
+	assert.Equal("Puma concolor", rows[1][len(ranks)-1].Name)
+
+	ranks, rows = stats.Align(nil)
+	assert.Empty(ranks)
+	assert.Empty(rows)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	fp := stats.Fingerprint(hs)
+	assert.Equal(64, len(fp))
+	assert.Equal(fp, stats.Fingerprint(hs))
+
+	rev := make([]stats.Hierarchy, len(hs))
+	for i := range hs {
+		rev[len(hs)-1-i] = hs[i]
+	}
+	assert.Equal(fp, stats.Fingerprint(rev))
+
+	assert.NotEqual(fp, stats.Fingerprint(hs[1:]))
+	dup := append([]stats.Hierarchy{hs[0]}, hs...)
+	assert.NotEqual(fp, stats.Fingerprint(dup))
+
+	// sha256 of an empty string
+	assert.Equal(
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stats.Fingerprint(nil),
+	)
+}
+
+func TestWithoutTaxon(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res := stats.New(hs, 0.5)
+	assert.Equal("Gastropoda", res.Class.Name)
+
+	rest := stats.WithoutTaxon(hs, "Gastropoda")
+	assert.Equal(69, len(hs))
+	assert.Equal(69-38, len(rest))
+	res = stats.New(rest, 0.5)
+	assert.Equal("Bivalvia", res.Class.Name)
+	assert.Equal(len(rest), res.NamesNum)
+}
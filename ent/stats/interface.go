@@ -8,3 +8,33 @@ type Hierarchy interface {
 	// hierarchy.
 	Taxons() []Taxon
 }
+
+// WeightedHierarchy is a Hierarchy that has a weight, for example
+// the number of occurrences of a name. Weights are used for calculation of
+// diversity indices. Negative weights are treated as 0.
+type WeightedHierarchy interface {
+	Hierarchy
+
+	// Weight returns the weight of the hierarchy.
+	Weight() float32
+}
+
+// Sourced is a Hierarchy that comes from a data source, for example
+// a taxonomic database. Sources are used for down-weighting of
+// over-represented sources (see OptSourceWeights).
+type Sourced interface {
+	Hierarchy
+
+	// Source returns the name of the data source of the hierarchy.
+	Source() string
+}
+
+// Tagged is a Hierarchy that belongs to a group, for example to a document
+// or a data source the name was found in. NewByTag calculates Stats for
+// every group.
+type Tagged interface {
+	Hierarchy
+
+	// Tag returns the name of the group of the hierarchy.
+	Tag() string
+}
@@ -0,0 +1,192 @@
+package stats
+
+// LazyStats calculates stats on demand. It keeps qualified taxons of
+// the names and calculates the distribution of a rank only when the rank is
+// requested for the first time, caching the result. It is useful when only
+// a few ranks are needed, for example only the kingdom and the MainTaxon.
+// LazyStats gives the same results as the corresponding fields of Stats.
+// LazyStats is not safe for concurrent use.
+type LazyStats struct {
+	h         []Hierarchy
+	threshold float32
+	opts      options
+	optList   []Option
+
+	// taxons contains qualified taxons of every counted name.
+	taxons [][]Taxon
+
+	// sources contains source weights of counted names, if
+	// OptSourceWeights is used.
+	sources []float64
+
+	// ranks contains cached data of ranks that were already requested.
+	ranks map[Rank]rankData
+}
+
+// NewLazy creates LazyStats. The arguments have the same meaning as for
+// New. Only qualification of names happens in NewLazy, all other
+// calculations are postponed until they are needed.
+func NewLazy(h []Hierarchy, threshold float32, opts ...Option) *LazyStats {
+	a := NewAggregator(threshold, opts...)
+	res := &LazyStats{
+		h:         h,
+		threshold: a.threshold,
+		opts:      a.opts,
+		optList:   opts,
+		taxons:    make([][]Taxon, 0, len(h)),
+		ranks:     make(map[Rank]rankData),
+	}
+	units := make(map[string]struct{})
+	for i := range h {
+		taxons, ok, _ := qualifiedTaxons(i, h[i], res.opts)
+		if !ok {
+			continue
+		}
+		if res.opts.countUnit == UnitSpecies {
+			key := speciesKey(taxons)
+			if _, ok := units[key]; ok && key != "" {
+				continue
+			}
+			units[key] = struct{}{}
+		}
+		res.taxons = append(res.taxons, taxons)
+		if res.opts.sourceWeights != nil {
+			res.sources = append(res.sources, a.sourceWeight(h[i]))
+		}
+	}
+	if reps := res.opts.representatives(res.taxons); reps != nil {
+		for i := range res.taxons {
+			res.taxons[i] = replaceTaxons(res.taxons[i], reps)
+		}
+	}
+	return res
+}
+
+// NamesNum returns the number of names used for the calculation. It has
+// the same meaning as Stats.NamesNum.
+func (l *LazyStats) NamesNum() int {
+	if len(l.taxons) < 2 {
+		return 0
+	}
+	return len(l.taxons)
+}
+
+// Prevalent returns the most prevalent taxon and its percentage for the
+// given rank the same way as Stats.Prevalent.
+func (l *LazyStats) Prevalent(rank Rank) (Taxon, float32) {
+	namesNum := l.NamesNum()
+	if namesNum == 0 || (rank != Species && !isMajorRank(rank)) {
+		return Taxon{}, 0
+	}
+	rd := l.rank(rank)
+	if rd.total == 0 {
+		return Taxon{}, 0
+	}
+	txn, pcent := maxTaxon(namesNum, rd)
+	isMax := isPlurality(rd, txn)
+	if l.opts.normalizePercentages && rd.total == namesNum {
+		top := normalizeDist(appendTaxDist(nil, namesNum, rd))
+		if isMax {
+			pcent = top
+		}
+	}
+	switch {
+	case isMax:
+		return txn, pcent
+	case rank == Kingdom:
+		return coDominant(rd)[0], pcent
+	default:
+		return Taxon{}, 0
+	}
+}
+
+// Distribution returns the distribution of names across taxons of the
+// given rank the same way as Stats.Distribution.
+func (l *LazyStats) Distribution(rank Rank) []TaxonDist {
+	namesNum := l.NamesNum()
+	if namesNum == 0 {
+		return nil
+	}
+	rd := l.rank(rank)
+	if rd.total == 0 {
+		return nil
+	}
+	res := appendTaxDist(nil, namesNum, rd)
+	sortTaxDist(res)
+	if l.opts.normalizePercentages && rd.total == namesNum {
+		normalizeDist(res)
+	}
+	return res
+}
+
+// MainTaxon returns the MainTaxon and its percentage. Only ranks allowed
+// for the MainTaxon are calculated, starting from the lowest one, until
+// the MainTaxon is found.
+func (l *LazyStats) MainTaxon() (Taxon, float32) {
+	namesNum := l.NamesNum()
+	if namesNum == 0 {
+		return Taxon{}, 0
+	}
+	for r := SubSpecies; r <= Empire; r++ {
+		if !l.opts.isMainTaxonRank(r) {
+			continue
+		}
+		rd := l.rank(r)
+		if rd.total == 0 {
+			continue
+		}
+		txn, pcent := maxTaxon(namesNum, rd)
+		if isMainTaxon(rd, txn, pcent, l.threshold, l.opts) {
+			return txn, pcent
+		}
+	}
+	return Taxon{}, 0
+}
+
+// Stats calculates all stats, the same as New would do.
+func (l *LazyStats) Stats() Stats {
+	return New(l.h, l.threshold, l.optList...)
+}
+
+// rank returns counts of taxons of the given rank, calculating them if
+// they are not cached yet. Ranks with fewer names than required by
+// OptMinRankSamples are empty.
+func (l *LazyStats) rank(rank Rank) rankData {
+	if rd, ok := l.ranks[rank]; ok {
+		return rd
+	}
+	rd := rankData{rank: rank, data: make(map[Taxon]int)}
+	if l.sources != nil {
+		rd.sourced = make(map[Taxon]float64)
+		for _, v := range l.sources {
+			rd.sourcedNames += v
+		}
+	}
+	for ii, taxons := range l.taxons {
+		for i := range taxons {
+			if taxons[i].Rank == rank {
+				rd.data[taxons[i]]++
+				rd.total++
+				if rd.sourced != nil {
+					rd.sourced[taxons[i]] += l.sources[ii]
+				}
+			}
+		}
+	}
+	if rd.total < l.opts.minRankSamples {
+		rd = rankData{rank: rank, data: make(map[Taxon]int)}
+	}
+	l.ranks[rank] = rd
+	return rd
+}
+
+// isMajorRank checks if a rank is one of kingdom, phylum, class, order,
+// family or genus.
+func isMajorRank(rank Rank) bool {
+	for _, v := range majorRanks {
+		if v == rank {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLazy(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		msg       string
+		hs        []stats.Hierarchy
+		threshold float32
+		opts      []stats.Option
+	}{
+		{"molluscs", testData(t), 0.5, nil},
+		{"molluscs 0.7", testData(t), 0.7, nil},
+		{"reptiles", taxons2(t, "reptiles.csv"), 0.5, nil},
+		{"fifty", fiftyFifty(), 0.5, nil},
+		{"species", taxons2(t, "reptiles.csv"), 0.5,
+			[]stats.Option{stats.OptCountUnit(stats.UnitSpecies)}},
+		{"single", testData(t)[:1], 0.5, nil},
+		{"sourced", sourcedGroup(t), 0.5, []stats.Option{
+			stats.OptSourceWeights(map[string]float32{"big": 0.2}),
+		}},
+	}
+
+	for _, v := range tests {
+		exp := stats.New(v.hs, v.threshold, v.opts...)
+		lazy := stats.NewLazy(v.hs, v.threshold, v.opts...)
+		assert.Equal(exp.NamesNum, lazy.NamesNum(), v.msg)
+		for _, r := range stats.Ranks() {
+			txn, pcent := exp.Prevalent(r)
+			lTxn, lPcent := lazy.Prevalent(r)
+			assert.Equal(txn, lTxn, v.msg)
+			assert.Equal(pcent, lPcent, v.msg)
+			if r.AtLeast(stats.SubSpecies) {
+				assert.Equal(exp.Distribution(r), lazy.Distribution(r), v.msg)
+			}
+		}
+		txn, pcent := lazy.MainTaxon()
+		assert.Equal(exp.MainTaxon, txn, v.msg)
+		assert.Equal(exp.MainTaxonPercentage, pcent, v.msg)
+		assert.True(exp.Equal(lazy.Stats()), v.msg)
+	}
+
+	lazy := stats.NewLazy(sourcedGroup(t), 0.5,
+		stats.OptSourceWeights(map[string]float32{"big": 0.2}))
+	txn, pcent := lazy.MainTaxon()
+	assert.Equal("Amphisbaenidae", txn.Name)
+	assert.Less(pcent, float32(0.69))
+}
+
+func BenchmarkNewLazy(b *testing.B) {
+	hs := taxons2(b, "reptiles.csv")
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			res := stats.New(hs, 0.5)
+			_, _ = res.Prevalent(stats.Kingdom)
+		}
+	})
+	b.Run("NewLazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			res := stats.NewLazy(hs, 0.5)
+			_, _ = res.Prevalent(stats.Kingdom)
+		}
+	})
+}
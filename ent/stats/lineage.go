@@ -0,0 +1,124 @@
+package stats
+
+import "sort"
+
+// ChildShare describes which share of names of a parent taxon belongs to
+// its child taxon.
+type ChildShare struct {
+	// Parent is the parent taxon.
+	Parent Taxon
+
+	// Child is the child taxon.
+	Child Taxon
+
+	// NamesNum is the number of names that belong to the child taxon.
+	NamesNum int
+
+	// Share is a value between 0 and 1 representing the share of names of
+	// the parent taxon that belong to the child taxon.
+	Share float32
+}
+
+// ChildShares returns shares of names of the children of all taxons with
+// the given name. A child is the next taxon of a hierarchy after the parent.
+// Shares do not add up to 1 if some names end at the parent taxon. Results
+// are sorted by shares in descending order, and then by IDs and names.
+func (s Stats) ChildShares(parentName string) []ChildShare {
+	var res []ChildShare
+	for parent, cs := range s.children {
+		if parent.Name != parentName {
+			continue
+		}
+		parentNum := s.rankCounts[parent.Rank][parent]
+		if parentNum == 0 {
+			continue
+		}
+		for child, num := range cs {
+			res = append(res, ChildShare{
+				Parent:   parent,
+				Child:    child,
+				NamesNum: num,
+				Share:    float32(num) / float32(parentNum),
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Share != res[j].Share {
+			return res[i].Share > res[j].Share
+		}
+		if res[i].Child != res[j].Child {
+			return taxonLess(res[i].Child, res[j].Child)
+		}
+		return taxonLess(res[i].Parent, res[j].Parent)
+	})
+	return res
+}
+
+// OutlierNames returns IDs of names that do not have a taxon with the given
+// ID at any rank. It shows which names prevent a higher taxon from becoming
+// the MainTaxon. The ID of a name is the ID of its most specific taxon (its
+// name, if the ID is empty). IDs are returned in the order the names were
+// given. With UnitSpecies every species is represented by its first name.
+func (s Stats) OutlierNames(targetTaxonID string) []string {
+	if targetTaxonID == "" {
+		return nil
+	}
+	var res []string
+	for _, m := range s.members {
+		taxons := m.taxons
+		if len(taxons) == 0 || hasTaxonID(taxons, targetTaxonID) {
+			continue
+		}
+		leaf := taxons[len(taxons)-1]
+		id := leaf.ID
+		if id == "" {
+			id = leaf.Name
+		}
+		res = append(res, id)
+	}
+	return res
+}
+
+func hasTaxonID(taxons []Taxon, id string) bool {
+	for i := range taxons {
+		if taxons[i].ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// DrillDown calculates Stats only for the names that belong to the
+// MainTaxon, with the same threshold and options. Only ranks lower than
+// the rank of the MainTaxon are allowed for the new MainTaxon, so
+// repeated calls go down the hierarchy and stop when no lower MainTaxon
+// can be found. Names keep their weights and source weights. It returns
+// Stats with ReasonNoNames if there is no MainTaxon.
+func (s Stats) DrillDown() Stats {
+	o := s.opts
+	o.mainTaxonRanks = make(map[Rank]struct{}, len(s.opts.mainTaxonRanks))
+	for k := range s.opts.mainTaxonRanks {
+		if k < s.MainTaxon.Rank {
+			o.mainTaxonRanks[k] = struct{}{}
+		}
+	}
+
+	a := newAggregator(s.threshold, o)
+	if s.MainTaxon.IsZero() {
+		return a.Stats()
+	}
+	for _, m := range s.members {
+		taxons := m.taxons
+		for i := range taxons {
+			if taxons[i].ID == s.MainTaxon.ID &&
+				taxons[i].Name == s.MainTaxon.Name &&
+				taxons[i].Rank == s.MainTaxon.Rank {
+				// members are already qualified, only their counts are added
+				a.inputCount++
+				a.count(taxons, 1, m.weight, m.source)
+				break
+			}
+		}
+	}
+	return a.Stats()
+}
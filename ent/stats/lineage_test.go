@@ -0,0 +1,128 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChildShares(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	cs := res.ChildShares("Gastropoda")
+	assert.Greater(len(cs), 1)
+	var sum float32
+	for _, v := range cs {
+		assert.Equal("Gastropoda", v.Parent.Name)
+		sum += v.Share
+	}
+	assert.InDelta(float32(1), sum, 0.0001)
+
+	cs = res.ChildShares("Mollusca")
+	assert.Equal("Gastropoda", cs[0].Child.Name)
+	assert.Equal(res.ClassPercentage, cs[0].Share)
+
+	assert.Empty(res.ChildShares("Chordata"))
+}
+
+func TestOutlierNames(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res := stats.New(hs, 0.5)
+	assert.Equal("Gastropoda", res.Class.Name)
+	outliers := res.OutlierNames(res.Class.ID)
+	assert.Equal(res.NamesNum-38, len(outliers))
+
+	rest := stats.WithoutTaxon(hs, "Gastropoda")
+	var exp []string
+	for _, h := range rest {
+		taxons := h.Taxons()
+		exp = append(exp, taxons[len(taxons)-1].ID)
+	}
+	assert.Equal(exp, outliers)
+
+	assert.Empty(res.OutlierNames(res.Phylum.ID))
+	assert.Nil(res.OutlierNames(""))
+}
+
+func TestDrillDown(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+	sub := res.DrillDown()
+	assert.Equal(38, sub.NamesNum)
+	assert.Equal(float32(1), sub.ClassPercentage)
+	// Neogastropoda has 18 of 38 gastropods
+	assert.Equal("Neogastropoda", sub.Order.Name)
+	assert.True(sub.MainTaxon.IsZero())
+
+	res = stats.New(testData(t), 0.45, stats.OptAllowMinorityThreshold(true))
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+	sub = res.DrillDown()
+	assert.Equal(38, sub.NamesNum)
+	assert.Equal(stats.Order, sub.MainTaxon.Rank)
+	assert.Equal(sub.Order, sub.MainTaxon)
+
+	prev := sub.MainTaxon.Rank
+	for i := 0; i < 10 && !sub.MainTaxon.IsZero(); i++ {
+		sub = sub.DrillDown()
+		if !sub.MainTaxon.IsZero() {
+			assert.Less(sub.MainTaxon.Rank, prev)
+			prev = sub.MainTaxon.Rank
+		}
+	}
+	assert.True(sub.MainTaxon.IsZero())
+	assert.Equal(stats.ReasonNoNames, sub.DrillDown().EmptyReason)
+}
+
+// weightedSourced is a hierarchy with a weight and a source.
+type weightedSourced struct {
+	stats.Hierarchy
+	weight float32
+	source string
+}
+
+func (w weightedSourced) Weight() float32 {
+	return w.weight
+}
+
+func (w weightedSourced) Source() string {
+	return w.source
+}
+
+func TestDrillDownWeights(t *testing.T) {
+	assert := assert.New(t)
+	var hs, gastropods []stats.Hierarchy
+	for i, h := range testData(t) {
+		ws := weightedSourced{Hierarchy: h, weight: float32(i%3 + 1)}
+		var isGastropod bool
+		for _, v := range h.Taxons() {
+			switch v.Name {
+			case "Neogastropoda":
+				ws.source = "big"
+			case "Gastropoda":
+				isGastropod = true
+			}
+		}
+		hs = append(hs, ws)
+		if isGastropod {
+			gastropods = append(gastropods, ws)
+		}
+	}
+	opt := stats.OptSourceWeights(map[string]float32{"big": 2})
+
+	res := stats.New(hs, 0.5, opt)
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+	sub := res.DrillDown()
+	assert.Equal(38, sub.NamesNum)
+	// Neogastropoda has 18 of 38 gastropods, but 36 of 56 source weights
+	assert.Equal("Neogastropoda", sub.MainTaxon.Name)
+	assert.InDelta(36.0/56, sub.MainTaxonPercentage, 0.0001)
+
+	exp := stats.New(gastropods, 0.5, opt)
+	for _, r := range []stats.Rank{stats.Order, stats.Family, stats.Genus} {
+		assert.InDelta(exp.ShannonIndex(r), sub.ShannonIndex(r), 1e-9)
+		assert.Equal(exp.Distribution(r), sub.Distribution(r))
+	}
+}
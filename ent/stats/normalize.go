@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultSynonyms maps names of kingdoms used by different sources to
+// the names used by the Catalogue of Life.
+var DefaultSynonyms = map[string]string{
+	"Metazoa":        "Animalia",
+	"Viridiplantae":  "Plantae",
+	"Eubacteria":     "Bacteria",
+	"Archaebacteria": "Archaea",
+	"Protista":       "Protozoa",
+	"Mycota":         "Fungi",
+}
+
+// DefaultPlaceholders contains lowercase parts of names that are used
+// for unplaced taxa instead of real names, for example
+// "Family incertae sedis".
+var DefaultPlaceholders = []string{
+	"incertae sedis",
+	"not assigned",
+	"unclassified",
+}
+
+// isPlaceholder checks if a name contains one of placeholders of the
+// options. The comparison is case-insensitive.
+func (o options) isPlaceholder(name string) bool {
+	if !o.skipPlaceholders {
+		return false
+	}
+	name = strings.ToLower(name)
+	for _, v := range o.placeholders {
+		if strings.Contains(name, strings.ToLower(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// canonicalRe matches a scientific name without authorship: a capitalized
+	// uninomial followed by lowercase epithets.
+	canonicalRe = regexp.MustCompile(`^\p{Lu}\p{Ll}+(?: \p{Ll}[\p{Ll}-]*)*`)
+
+	// authorshipRe matches authorship that starts with a capital letter or
+	// a parenthesis and ends with a year.
+	authorshipRe = regexp.MustCompile(`^[(\p{Lu}].*\d{4}\)?$`)
+)
+
+// stripAuthorship removes authorship and year from the end of a name,
+// for example "Bubo bubo (Linnaeus, 1758)" becomes "Bubo bubo". To avoid
+// changes of legitimate names, authorship is only removed if it contains
+// a year.
+func stripAuthorship(name string) string {
+	canonical := canonicalRe.FindString(name)
+	if canonical == "" {
+		return name
+	}
+	rest := strings.TrimSpace(name[len(canonical):])
+	if rest == "" || !authorshipRe.MatchString(rest) {
+		return name
+	}
+	return canonical
+}
+
+var (
+	// binomialRe matches a species name without authorship.
+	binomialRe = regexp.MustCompile(`^\p{Lu}\p{Ll}+ \p{Ll}[\p{Ll}-]+$`)
+
+	// uninomialRe matches a single capitalized word.
+	uninomialRe = regexp.MustCompile(`^\p{Lu}\p{Ll}+$`)
+)
+
+// guessRank infers a rank from the shape of a name. A binomial is
+// a species, a single capitalized word is a genus if it is the last taxon
+// of a hierarchy. For all other names it returns Unknown.
+func guessRank(name string, isLeaf bool) Rank {
+	switch {
+	case binomialRe.MatchString(name):
+		return Species
+	case isLeaf && uninomialRe.MatchString(name):
+		return Genus
+	default:
+		return Unknown
+	}
+}
+
+// normalize changes a taxon according to the options.
+func (o options) normalize(t Taxon) Taxon {
+	if o.normalizeUnicode {
+		t.Name = norm.NFC.String(t.Name)
+	}
+	if o.stripAuthorship {
+		t.Name = stripAuthorship(t.Name)
+	}
+	if o.canonicalAll || (o.canonicalKingdoms && t.Rank == Kingdom) {
+		t = o.canonicalize(t)
+	}
+	return t
+}
+
+// canonicalize replaces a synonym of a taxon name with its canonical name.
+// The ID of a synonym belongs to a different source, so it is removed.
+// Taxons with the same canonical name are merged later and get one
+// representative ID (see representatives).
+func (o options) canonicalize(t Taxon) Taxon {
+	if name, ok := o.synonyms[t.Name]; ok {
+		t.Name = name
+		t.ID = ""
+	} else if !o.isCanonical(t.Name) {
+		return t
+	}
+	t.RankStr = t.Rank.String()
+	return t
+}
+
+// isCanonicalized checks if a taxon might have been changed by
+// canonicalize.
+func (o options) isCanonicalized(t Taxon) bool {
+	if !o.canonicalAll && !(o.canonicalKingdoms && t.Rank == Kingdom) {
+		return false
+	}
+	return o.isCanonical(t.Name)
+}
+
+// representatives returns replacements for canonicalized taxons, so all
+// taxons with the same canonical name and rank become one taxon. Its ID is
+// the smallest non-empty ID of such taxons. It returns nil if nothing has
+// to be replaced.
+func (o options) representatives(names [][]Taxon) map[Taxon]Taxon {
+	if !o.canonicalAll && !o.canonicalKingdoms {
+		return nil
+	}
+	type key struct {
+		name string
+		rank Rank
+	}
+	groups := make(map[key][]Taxon)
+	for _, taxons := range names {
+		for _, t := range taxons {
+			if !o.isCanonicalized(t) {
+				continue
+			}
+			k := key{t.Name, t.Rank}
+			if !slices.Contains(groups[k], t) {
+				groups[k] = append(groups[k], t)
+			}
+		}
+	}
+
+	var res map[Taxon]Taxon
+	for _, ts := range groups {
+		if len(ts) < 2 {
+			continue
+		}
+		rep := ts[0]
+		for _, t := range ts[1:] {
+			if rep.ID == "" || (t.ID != "" && t.ID < rep.ID) {
+				rep = t
+			}
+		}
+		if res == nil {
+			res = make(map[Taxon]Taxon)
+		}
+		for _, t := range ts {
+			if t != rep {
+				res[t] = rep
+			}
+		}
+	}
+	return res
+}
+
+// replaceTaxons returns taxons with replacements from reps. The original
+// slice is not modified.
+func replaceTaxons(taxons []Taxon, reps map[Taxon]Taxon) []Taxon {
+	var res []Taxon
+	for i := range taxons {
+		rep, ok := reps[taxons[i]]
+		if !ok {
+			continue
+		}
+		if res == nil {
+			res = slices.Clone(taxons)
+		}
+		res[i] = rep
+	}
+	if res == nil {
+		return taxons
+	}
+	return res
+}
+
+// isCanonical checks if a name is a canonical name of some synonym.
+func (o options) isCanonical(name string) bool {
+	for _, v := range o.synonyms {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
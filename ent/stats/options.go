@@ -0,0 +1,405 @@
+package stats
+
+import "log/slog"
+
+// Option is a type of functional options that modify the way stats are
+// calculated.
+type Option func(*options)
+
+// CountUnit determines what is considered as a unit of counting.
+type CountUnit int
+
+const (
+	// UnitName counts every qualified name separately. It is the default.
+	UnitName CountUnit = iota
+
+	// UnitSpecies counts every unique species only once. Names are
+	// deduplicated by the ID of their species-level taxon (or by the name,
+	// if the ID is empty). Names that do not reach species are deduplicated
+	// by their genus. Subspecies of the same species collapse into one unit.
+	UnitSpecies
+)
+
+// MajorityMode determines how MainTaxon is selected.
+type MajorityMode int
+
+const (
+	// MajorityStrict selects the lowest taxon that contains more names than
+	// the threshold. It is the default.
+	MajorityStrict MajorityMode = iota
+
+	// MajorityRelative selects the lowest taxon that contains more names than
+	// any other taxon of the same rank (a plurality), even if it contains less
+	// than 50% of names. The threshold is ignored in this mode.
+	MajorityRelative
+)
+
+type options struct {
+	countUnit   CountUnit
+	logger      *slog.Logger
+	minPoolRank Rank
+
+	inclusiveThreshold bool
+	mainTaxonRanks     map[Rank]struct{}
+	majorityMode       MajorityMode
+	allowMinority      bool
+	rankThresholds     map[Rank]float32
+
+	inspect func(rank Rank, counts map[string]int)
+
+	canonicalKingdoms bool
+	canonicalAll      bool
+	synonyms          map[string]string
+
+	strictTree bool
+
+	minRankSamples int
+
+	normalizePercentages bool
+
+	stripAuthorship bool
+
+	rankHeuristics bool
+
+	botanicalRankNames bool
+
+	skipMinorRanks bool
+
+	normalizeUnicode bool
+
+	requireRank Rank
+
+	concurrency int
+
+	sourceWeights map[string]float32
+
+	skipPlaceholders bool
+	placeholders     []string
+
+	qualifyRanks func(Rank) bool
+}
+
+// OptCountUnit sets the unit of counting. With UnitSpecies the NamesNum
+// field of Stats contains the number of unique species (and genera for
+// genus-level names) instead of the number of qualified names.
+func OptCountUnit(u CountUnit) Option {
+	return func(o *options) {
+		o.countUnit = u
+	}
+}
+
+// OptMinPoolRank sets the highest rank a name has to reach to qualify for
+// the calculation of stats. By default names have to have a taxon of genus
+// rank or lower. The NamesNum field of Stats contains the number of names
+// that qualified.
+func OptMinPoolRank(r Rank) Option {
+	return func(o *options) {
+		o.minPoolRank = r
+	}
+}
+
+// OptQualifyRanks sets a function that decides which ranks make a name
+// qualify for the calculation of stats. It replaces the rule of
+// OptMinPoolRank, so for example names of hybrid genera or species
+// aggregates with unusual ranks can be admitted. If the function is nil,
+// the rule of OptMinPoolRank is used, it is the default.
+func OptQualifyRanks(fn func(Rank) bool) Option {
+	return func(o *options) {
+		o.qualifyRanks = fn
+	}
+}
+
+// qualifies checks if a taxon of the given rank makes a name qualified for
+// the calculation.
+func (o options) qualifies(r Rank) bool {
+	if o.qualifyRanks != nil {
+		return o.qualifyRanks(r)
+	}
+	return r.Between(SubSpecies, o.minPoolRank)
+}
+
+// OptLogger sets a logger that receives debug records about dropped
+// hierarchies, unknown ranks and ties for prevalent taxa. Nothing is
+// logged if the logger is nil.
+func OptLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// OptInclusiveThreshold determines if a taxon that contains exactly
+// the threshold percentage of names can become the MainTaxon. By default
+// (false) the percentage has to be strictly greater than the threshold.
+func OptInclusiveThreshold(b bool) Option {
+	return func(o *options) {
+		o.inclusiveThreshold = b
+	}
+}
+
+// OptMainTaxonRanks sets ranks at which MainTaxon can be selected. By
+// default only kingdom, phylum, class, order, family and genus are allowed.
+// The search for MainTaxon goes from lower to higher ranks, so if
+// the threshold is first met at a rank that is not allowed, the search
+// continues to the next allowed higher rank.
+func OptMainTaxonRanks(rs []Rank) Option {
+	return func(o *options) {
+		o.mainTaxonRanks = make(map[Rank]struct{}, len(rs))
+		for _, v := range rs {
+			o.mainTaxonRanks[v] = struct{}{}
+		}
+	}
+}
+
+func (o options) isMainTaxonRank(r Rank) bool {
+	_, ok := o.mainTaxonRanks[r]
+	return ok
+}
+
+// OptMajorityMode sets the way MainTaxon is selected. In MajorityRelative
+// mode the threshold is ignored, so OptAllowMinorityThreshold and
+// OptInclusiveThreshold have no effect.
+func OptMajorityMode(m MajorityMode) Option {
+	return func(o *options) {
+		o.majorityMode = m
+	}
+}
+
+// OptAllowMinorityThreshold allows thresholds lower than 0.5. By default
+// such thresholds are changed to 0.5, so MainTaxon always contains
+// a majority of names.
+func OptAllowMinorityThreshold(b bool) Option {
+	return func(o *options) {
+		o.allowMinority = b
+	}
+}
+
+// OptRankThresholds sets thresholds for MainTaxon for particular ranks.
+// The global threshold is used for ranks that are not in the map.
+// Thresholds lower than 0.5 are changed to 0.5, unless
+// OptAllowMinorityThreshold is used.
+func OptRankThresholds(m map[Rank]float32) Option {
+	return func(o *options) {
+		o.rankThresholds = make(map[Rank]float32, len(m))
+		for k, v := range m {
+			o.rankThresholds[k] = v
+		}
+	}
+}
+
+// threshold returns a threshold for a rank.
+func (o options) threshold(r Rank, global float32) float32 {
+	if v, ok := o.rankThresholds[r]; ok {
+		return v
+	}
+	return global
+}
+
+// OptInspect sets a diagnostic callback that receives the number of names
+// for taxons of every rank, before empty ranks are removed and stats are
+// calculated. It is called once per rank, from the highest to the lowest,
+// with a copy of the counts keyed by taxon names. The callback does not
+// affect results.
+func OptInspect(fn func(rank Rank, counts map[string]int)) Option {
+	return func(o *options) {
+		o.inspect = fn
+	}
+}
+
+// OptCanonicalizeKingdoms replaces synonymous names of kingdoms with their
+// canonical names (for example "Metazoa" with "Animalia"), so a group of
+// names from different sources does not split between kingdoms. Merged
+// taxons keep the smallest of their non-empty IDs. By default
+// DefaultSynonyms are used.
+func OptCanonicalizeKingdoms(b bool) Option {
+	return func(o *options) {
+		o.canonicalKingdoms = b
+	}
+}
+
+// OptCanonicalizeAllRanks applies canonicalization of names to taxons of
+// all ranks, not only to kingdoms.
+func OptCanonicalizeAllRanks(b bool) Option {
+	return func(o *options) {
+		o.canonicalAll = b
+	}
+}
+
+// OptSynonyms overrides DefaultSynonyms used for canonicalization of names.
+// Keys of the map are synonyms, values are canonical names.
+func OptSynonyms(m map[string]string) Option {
+	return func(o *options) {
+		o.synonyms = m
+	}
+}
+
+// OptStrictTree enables validation of hierarchies. A hierarchy that
+// contains more than one taxon of the same rank (for example two kingdoms)
+// is dropped by New and the Aggregator, and makes NewWithError return
+// ErrInconsistentTree.
+func OptStrictTree(b bool) Option {
+	return func(o *options) {
+		o.strictTree = b
+	}
+}
+
+// OptMinRankSamples sets the minimal number of names that have to reach
+// a rank for the rank to be used in the calculation. Ranks with fewer
+// names are treated as empty. The default is 1.
+func OptMinRankSamples(n int) Option {
+	return func(o *options) {
+		o.minRankSamples = n
+	}
+}
+
+// OptNormalizePercentages makes percentages of a distribution (Kingdoms,
+// Genera, Distribution) sum up to exactly 1. Because of float rounding
+// the sum of raw percentages can be slightly different, for example
+// 1.0000001. The difference is added to the percentage of the largest
+// taxon, distorting it by a tiny fraction. Only distributions of ranks
+// that contain all names are normalized. Such distributions are sorted by
+// percentage in descending order. By default percentages are not modified.
+func OptNormalizePercentages(b bool) Option {
+	return func(o *options) {
+		o.normalizePercentages = b
+	}
+}
+
+// OptStripAuthorship removes authorship and year from names of taxons
+// before counting, so "Bubo bubo (Linnaeus, 1758)" and "Bubo bubo" are
+// counted as the same taxon (if their IDs are the same too). Only
+// authorship that contains a year is removed.
+func OptStripAuthorship(b bool) Option {
+	return func(o *options) {
+		o.stripAuthorship = b
+	}
+}
+
+// OptRankHeuristics enables inference of ranks of taxons that have no
+// rank string. A binomial name is considered a species, and a single
+// capitalized word at the end of a hierarchy is considered a genus. Other
+// ranks are never inferred.
+func OptRankHeuristics(b bool) Option {
+	return func(o *options) {
+		o.rankHeuristics = b
+	}
+}
+
+// OptBotanicalRankNames sets RankStr of the Phylum and MainTaxon fields to
+// "division" if they are of the phylum rank and the prevalent kingdom is
+// Plantae or Fungi. It only changes the output, the calculation and
+// parsing of ranks are not affected.
+func OptBotanicalRankNames(b bool) Option {
+	return func(o *options) {
+		o.botanicalRankNames = b
+	}
+}
+
+// OptSkipMinorRanks makes the calculation ignore taxons of minor ranks
+// (see Rank.IsMinor). It speeds up processing of deep hierarchies when only
+// the major ranks are important. Distributions of minor ranks are empty
+// then, and ChildShares connect major ranks directly. Minor ranks still
+// count for the qualification of names (see OptMinPoolRank).
+func OptSkipMinorRanks(b bool) Option {
+	return func(o *options) {
+		o.skipMinorRanks = b
+	}
+}
+
+// OptNormalizeUnicode converts names of taxons to the Unicode
+// normalization form NFC before counting, so names with composed and
+// decomposed accented characters (for example "é" as one code point, or
+// as "e" with a combining accent) are counted as the same taxon. It is
+// enabled by default.
+func OptNormalizeUnicode(b bool) Option {
+	return func(o *options) {
+		o.normalizeUnicode = b
+	}
+}
+
+// OptRequireRank drops names that do not have a taxon of the given rank,
+// for example only names with a genus are used with OptRequireRank(Genus).
+// Unlike OptMinPoolRank, a name that reaches a lower rank does not qualify
+// if it misses the required rank. Dropped names are counted in
+// DroppedNames and reported by a WarnMissingRank warning. Empty and Unknown
+// ranks disable the requirement, it is the default.
+func OptRequireRank(r Rank) Option {
+	return func(o *options) {
+		o.requireRank = r
+	}
+}
+
+// lacksRequiredRank checks if a rank is required, and if taxons do not
+// have it.
+func (o options) lacksRequiredRank(taxons []Taxon) bool {
+	if o.requireRank.AtMost(Unknown) {
+		return false
+	}
+	for i := range taxons {
+		if taxons[i].Rank == o.requireRank {
+			return false
+		}
+	}
+	return true
+}
+
+// OptConcurrency sets the number of groups NewBatch and NewByTag process
+// in parallel. Values less than 1 use the default, which is
+// runtime.GOMAXPROCS(0). The order of results does not depend on it.
+// Calculation of Stats for one group is not affected.
+func OptConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// OptSourceWeights sets weights of data sources, so names from
+// over-represented sources can be down-weighted. Names of hierarchies that
+// implement Sourced contribute the weight of their source to percentages
+// of taxons, and thus to the MainTaxon and prevalent taxons. Names from
+// unknown sources, and names that do not implement Sourced, have weight 1.
+// Negative weights are treated as 0. Numbers of names, such as NamesNum of
+// TaxonDist, stay unweighted.
+func OptSourceWeights(m map[string]float32) Option {
+	return func(o *options) {
+		o.sourceWeights = m
+	}
+}
+
+// OptSkipPlaceholders skips taxons with placeholder names, such as
+// "Incertae sedis" or "Not assigned", so they do not appear in
+// distributions as real taxa. A placeholder taxon does not qualify a name,
+// but other taxons of its hierarchy are used. By default DefaultPlaceholders
+// are skipped.
+func OptSkipPlaceholders(b bool) Option {
+	return func(o *options) {
+		o.skipPlaceholders = b
+	}
+}
+
+// OptPlaceholders overrides DefaultPlaceholders used by OptSkipPlaceholders.
+// A name is a placeholder if it contains one of the given strings, ignoring
+// case.
+func OptPlaceholders(s []string) Option {
+	return func(o *options) {
+		o.placeholders = s
+	}
+}
+
+func newOptions(opts []Option) options {
+	res := options{
+		minPoolRank:      Genus,
+		minRankSamples:   1,
+		normalizeUnicode: true,
+		skipPlaceholders: true,
+		placeholders:     DefaultPlaceholders,
+		mainTaxonRanks:   make(map[Rank]struct{}, len(majorRanks)),
+		synonyms:         DefaultSynonyms,
+	}
+	for _, v := range majorRanks {
+		res.mainTaxonRanks[v] = struct{}{}
+	}
+	for _, opt := range opts {
+		opt(&res)
+	}
+	return res
+}
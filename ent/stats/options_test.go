@@ -0,0 +1,753 @@
+package stats_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptLogger(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "taxons2.csv")
+	h := &captureHandler{}
+	res := stats.New(hs, 0.5, stats.OptLogger(slog.New(h)))
+	assert.Equal(8, res.NamesNum)
+
+	var dropped int
+	for _, v := range h.records {
+		if v.Level != slog.LevelDebug {
+			continue
+		}
+		if v.Message == "dropped hierarchy without taxa of the minimal pool rank or lower" {
+			dropped++
+		}
+	}
+	assert.Equal(1, dropped)
+}
+
+func TestOptInclusiveThreshold(t *testing.T) {
+	assert := assert.New(t)
+	hs := fiftyFifty()
+	res := stats.New(hs, 0.5)
+	assert.Equal("", res.MainTaxon.Name)
+
+	res = stats.New(hs, 0.5, stats.OptInclusiveThreshold(false))
+	assert.Equal("", res.MainTaxon.Name)
+
+	res = stats.New(hs, 0.5, stats.OptInclusiveThreshold(true))
+	assert.Equal("Magnoliopsida", res.MainTaxon.Name)
+	assert.Equal(float32(0.5), res.MainTaxonPercentage)
+}
+
+func TestOptMainTaxonRanks(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "unranked|kingdom|phylum|class|order|family|subfamily|genus|species"
+	hs := []stats.Hierarchy{
+		newHry(
+			"Biota|Animalia|Chordata|Aves|Strigiformes|Strigidae|Striginae|Bubo|Bubo bubo",
+			ranks,
+			"5T6MX|N|CH2|V2|466|GQX|KDK|3DQQ|NKSD",
+		),
+		newHry(
+			"Biota|Animalia|Chordata|Aves|Strigiformes|Strigidae|Striginae|Strix|Strix aluco",
+			ranks,
+			"5T6MX|N|CH2|V2|466|GQX|KDK|6W7S|6W8K",
+		),
+		newHry(
+			"Biota|Animalia|Chordata|Aves|Strigiformes|Strigidae|Surniinae|Athene|Athene noctua",
+			ranks,
+			"5T6MX|N|CH2|V2|466|GQX|KDL|3DH2|3DH5",
+		),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal("Strigidae", res.MainTaxon.Name)
+	assert.Equal(float32(1.0), res.MainTaxonPercentage)
+
+	res = stats.New(hs, 0.5, stats.OptMainTaxonRanks(stats.Ranks()))
+	assert.Equal("Striginae", res.MainTaxon.Name)
+	assert.Equal(stats.SubFamily, res.MainTaxon.Rank)
+
+	res = stats.New(hs, 0.5, stats.OptMainTaxonRanks([]stats.Rank{stats.Class}))
+	assert.Equal("Aves", res.MainTaxon.Name)
+}
+
+func TestOptCanonicalizeKingdoms(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus"
+	hs := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo", ranks,
+			"N|CH2|V2|466|GQX|3DQQ"),
+		newHry("Animalia|Chordata|Mammalia|Carnivora|Felidae|Puma", ranks,
+			"N|CH2|6224G|VS|623RM|75F9"),
+		newHry("Metazoa|Chordata|Aves|Passeriformes|Corvidae|Corvus", ranks,
+			"33208|CH2|V2|H4|C8R|6DBK"),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal(2, len(res.Kingdoms))
+	assert.InDelta(float32(0.67), res.KingdomPercentage, 0.01)
+
+	res = stats.New(hs, 0.5, stats.OptCanonicalizeKingdoms(true))
+	assert.Equal(1, len(res.Kingdoms))
+	assert.Equal("Animalia", res.Kingdom.Name)
+	assert.Equal("N", res.Kingdom.ID)
+	assert.Equal(float32(1), res.KingdomPercentage)
+	assert.Equal(float32(1), res.FractionUnder("N"))
+	lazy := stats.NewLazy(hs, 0.5, stats.OptCanonicalizeKingdoms(true))
+	txn, _ := lazy.Prevalent(stats.Kingdom)
+	assert.Equal(res.Kingdom, txn)
+
+	// IDs of pure CoL data are kept
+	res = stats.New(hs[:2], 0.5, stats.OptCanonicalizeKingdoms(true))
+	assert.Equal("N", res.Kingdom.ID)
+	assert.Equal(float32(1), res.FractionUnder("N"))
+
+	res = stats.New(hs, 0.5,
+		stats.OptCanonicalizeKingdoms(true),
+		stats.OptSynonyms(map[string]string{"Animalia": "Metazoa"}),
+	)
+	assert.Equal("Metazoa", res.Kingdom.Name)
+	assert.Equal("33208", res.Kingdom.ID)
+	assert.Equal(float32(1), res.KingdomPercentage)
+
+	// Aves is not a kingdom
+	syn := map[string]string{"Aves": "Mammalia"}
+	res = stats.New(hs, 0.5,
+		stats.OptCanonicalizeKingdoms(true),
+		stats.OptSynonyms(syn),
+	)
+	assert.Equal("Aves", res.Class.Name)
+	res = stats.New(hs, 0.5,
+		stats.OptCanonicalizeAllRanks(true),
+		stats.OptSynonyms(syn),
+	)
+	assert.Equal("Mammalia", res.Class.Name)
+	assert.Equal(float32(1), res.ClassPercentage)
+}
+
+func TestOptMajorityMode(t *testing.T) {
+	assert := assert.New(t)
+	relative := stats.OptMajorityMode(stats.MajorityRelative)
+
+	// puma and plantago tie at every rank
+	hs := fiftyFifty()
+	res := stats.New(hs[1:3], 0.5, relative)
+	assert.True(res.MainTaxon.IsZero())
+
+	// Magnoliopsida contains 2 names, Aves and Mammalia 1 name each
+	res = stats.New(hs, 0.5)
+	assert.True(res.MainTaxon.IsZero())
+	res = stats.New(hs, 0.5, relative)
+	assert.Equal("Magnoliopsida", res.MainTaxon.Name)
+
+	hs = nil
+	for i, v := range []int{4, 3, 3} {
+		for ii := 0; ii < v; ii++ {
+			h, err := stats.ParseHierarchy(
+				fmt.Sprintf("Animalia|Mollusca|Gastropoda|Family%d|Genus%d%d", i, i, ii),
+				"kingdom|phylum|class|family|genus",
+				fmt.Sprintf("N|M2L|7NF3Y|F%d|G%d%d", i, i, ii),
+			)
+			assert.Nil(err)
+			hs = append(hs, h)
+		}
+	}
+	res = stats.New(hs, 0.5)
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+	res = stats.New(hs, 0.5, relative)
+	assert.Equal("Family0", res.MainTaxon.Name)
+	assert.Equal(float32(0.4), res.MainTaxonPercentage)
+
+	// relative mode ignores the threshold
+	res = stats.New(hs, 0.3,
+		relative,
+		stats.OptAllowMinorityThreshold(true),
+	)
+	assert.Equal("Family0", res.MainTaxon.Name)
+}
+
+func TestOptAllowMinorityThreshold(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res := stats.New(hs, 0.2)
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+	res = stats.New(hs, 0.2, stats.OptAllowMinorityThreshold(true))
+	assert.Equal("Neogastropoda", res.MainTaxon.Name)
+}
+
+func TestOptRankThresholds(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res := stats.New(hs, 0.5)
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+
+	ths := map[stats.Rank]float32{stats.Phylum: 0.95, stats.Class: 0.6}
+	res = stats.New(hs, 0.5, stats.OptRankThresholds(ths))
+	assert.Equal("Mollusca", res.MainTaxon.Name)
+
+	res = stats.New(hs, 0.7)
+	assert.Equal("Mollusca", res.MainTaxon.Name)
+	ths = map[stats.Rank]float32{stats.Class: 0.5}
+	res = stats.New(hs, 0.7, stats.OptRankThresholds(ths))
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+
+	ths = map[stats.Rank]float32{stats.Order: 0.2}
+	res = stats.New(hs, 0.7, stats.OptRankThresholds(ths))
+	assert.Equal("Mollusca", res.MainTaxon.Name)
+	assert.Equal(float32(0.2), ths[stats.Order])
+	res = stats.New(hs, 0.7,
+		stats.OptRankThresholds(ths),
+		stats.OptAllowMinorityThreshold(true),
+	)
+	assert.Equal("Neogastropoda", res.MainTaxon.Name)
+}
+
+func TestOptMinPoolRank(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	res := stats.New(hs, 0.5)
+	assert.Equal(619, res.NamesNum)
+	assert.Equal(9, res.DroppedNames)
+
+	res = stats.New(hs, 0.5, stats.OptMinPoolRank(stats.Genus))
+	assert.Equal(9, res.DroppedNames)
+
+	res = stats.New(hs, 0.5, stats.OptMinPoolRank(stats.Family))
+	assert.Less(res.DroppedNames, 9)
+	assert.Equal(628, res.NamesNum+res.DroppedNames)
+
+	res = stats.New(hs, 0.5, stats.OptMinPoolRank(stats.Species))
+	assert.Greater(res.DroppedNames, 9)
+}
+
+func TestOptQualifyRanks(t *testing.T) {
+	assert := assert.New(t)
+	hs := []stats.Hierarchy{
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Rosaceae|Rosa|Rosa canina",
+			"kingdom|phylum|class|family|genus|species", "P|T|M|R|RS|1"),
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Rosaceae|Rosa|Rosa sect. Caninae",
+			"kingdom|phylum|class|family|genus|subgenus", "P|T|M|R|RS|2"),
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Rosaceae|Rosa",
+			"kingdom|phylum|class|family|genus", "P|T|M|R|RS"),
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Rosaceae|× Crataemespilus",
+			"kingdom|phylum|class|family|nothogenus", "P|T|M|R|3"),
+	}
+
+	// the default rule is the same as OptMinPoolRank(Genus)
+	res := stats.New(hs, 0.5)
+	assert.Equal(3, res.NamesNum)
+	exp := stats.New(hs, 0.5, stats.OptQualifyRanks(func(r stats.Rank) bool {
+		return r.Between(stats.SubSpecies, stats.Genus)
+	}))
+	assert.True(res.Equal(exp))
+
+	// subgenus-qualified names are used, genus-only names are not
+	res = stats.New(hs, 0.5, stats.OptQualifyRanks(func(r stats.Rank) bool {
+		return r.Between(stats.SubSpecies, stats.SubGenus)
+	}))
+	assert.Equal(2, res.NamesNum)
+	assert.Equal(2, res.DroppedNames)
+
+	// hybrid genera with unrecognized ranks are admitted too
+	res = stats.New(hs, 0.5, stats.OptQualifyRanks(func(r stats.Rank) bool {
+		return r == stats.Unknown || r.Between(stats.SubSpecies, stats.Genus)
+	}))
+	assert.Equal(4, res.NamesNum)
+	assert.Equal(0, res.DroppedNames)
+
+	// the function overrides OptMinPoolRank
+	res = stats.New(hs, 0.5,
+		stats.OptMinPoolRank(stats.Family),
+		stats.OptQualifyRanks(func(r stats.Rank) bool {
+			return r == stats.Species || r == stats.SubGenus
+		}),
+	)
+	assert.Equal(2, res.NamesNum)
+}
+
+func TestOptInspect(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	var ranks []stats.Rank
+	data := make(map[stats.Rank]map[string]int)
+	inspect := func(rank stats.Rank, counts map[string]int) {
+		ranks = append(ranks, rank)
+		data[rank] = counts
+		// changes of counts do not affect results
+		if rank == stats.Kingdom {
+			counts["Animalia"] = 0
+		}
+	}
+	res := stats.New(hs, 0.5, stats.OptInspect(inspect))
+	assert.Equal(stats.Ranks(), ranks)
+	assert.Equal(1, len(data[stats.Kingdom]))
+	assert.Empty(data[stats.SuperKingdom])
+	assert.Equal(38, data[stats.Class]["Gastropoda"])
+	assert.True(res.Equal(stats.New(hs, 0.5)))
+	assert.Equal(float32(1), res.KingdomPercentage)
+
+	data = make(map[stats.Rank]map[string]int)
+	inspect = func(rank stats.Rank, counts map[string]int) {
+		data[rank] = counts
+	}
+	res = stats.New(hs, 0.5, stats.OptInspect(inspect))
+	assert.Equal(res.NamesNum, data[stats.Kingdom]["Animalia"])
+}
+
+func TestOptStrictTree(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	bad := newHry("Animalia|Plantae|Chordata|Aves|Bubo",
+		"kingdom|kingdom|phylum|class|genus", "N|P|CH2|V2|3DQQ")
+	hs = append(hs, bad)
+
+	res, err := stats.NewWithError(hs, 0.5)
+	assert.Nil(err)
+	assert.Equal(70, res.NamesNum)
+
+	_, err = stats.NewWithError(hs, 0.5, stats.OptStrictTree(true))
+	assert.ErrorIs(err, stats.ErrInconsistentTree)
+	assert.Contains(err.Error(), "hierarchy 69")
+
+	res = stats.New(hs, 0.5, stats.OptStrictTree(true))
+	assert.Equal(69, res.NamesNum)
+	assert.Equal(1, res.DroppedNames)
+
+	res, err = stats.NewWithError(hs[:69], 0.5, stats.OptStrictTree(true))
+	assert.Nil(err)
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+
+	// skipped placeholders and hierarchies that do not qualify are
+	// not checked
+	ph := newHry("Animalia|Chordata|Aves|Incertae sedis|Not assigned|Bubo",
+		"kingdom|phylum|class|family|family|genus", "N|CH2|V2|is|na|3DQQ")
+	high := newHry("Animalia|Plantae|Chordata",
+		"kingdom|kingdom|phylum", "N|P|CH2")
+	hs = append(hs[:69:69], ph, high)
+	res = stats.New(hs, 0.5, stats.OptStrictTree(true))
+	assert.Equal(70, res.NamesNum)
+	resErr, err := stats.NewWithError(hs, 0.5, stats.OptStrictTree(true))
+	assert.Nil(err)
+	assert.True(res.Equal(resErr))
+}
+
+func TestOptMinRankSamples(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus|species"
+	hs := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubonini|Bubo|Bubo bubo",
+			"kingdom|phylum|class|order|family|tribe|genus|species",
+			"N|CH2|V2|466|GQX|BB|3DQQ|1"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Strix|Strix aluco",
+			ranks, "N|CH2|V2|466|GQX|6W7S|2"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Otus|Otus scops",
+			ranks, "N|CH2|V2|466|GQX|6WD|3"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Tytonidae|Tyto|Tyto alba",
+			ranks, "N|CH2|V2|466|GQW|6Y1F|4"),
+		newHry("Animalia|Chordata|Aves|Passeriformes|Corvidae|Corvus|Corvus corax",
+			ranks, "N|CH2|V2|PSS|FDR|4CR|5"),
+	}
+	res := stats.New(hs, 0.5)
+	tribes := res.Distribution(stats.Tribe)
+	assert.Equal(1, len(tribes))
+	assert.Equal("Bubonini", tribes[0].Name)
+
+	res = stats.New(hs, 0.5, stats.OptMinRankSamples(5))
+	assert.Nil(res.Distribution(stats.Tribe))
+	assert.Equal("Strigidae", res.Family.Name)
+	assert.Equal("Strigidae", res.MainTaxon.Name)
+	// suppressed ranks still count for the resolution
+	assert.Equal(float32(0.2), res.ResolutionRate(stats.Tribe))
+
+	res = stats.New(hs[:3], 0.5, stats.OptMinRankSamples(5))
+	assert.Equal(float32(1), res.GenusResolutionRate)
+	assert.Equal(float32(1), res.ResolutionRate(stats.Species))
+
+	res = stats.New(hs, 0.5, stats.OptMinRankSamples(6))
+	assert.Equal("", res.Kingdom.Name)
+	assert.True(res.MainTaxon.IsZero())
+	assert.Equal(5, res.NamesNum)
+}
+
+func TestOptNormalizePercentages(t *testing.T) {
+	assert := assert.New(t)
+	var hs []stats.Hierarchy
+	for i := 0; i < 10; i++ {
+		g := fmt.Sprintf("Genus%d", i)
+		hs = append(hs, newHry(
+			"Animalia|Chordata|Aves|Strigiformes|Strigidae|"+g,
+			"kingdom|phylum|class|order|family|genus",
+			"N|CH2|V2|466|GQX|"+g,
+		))
+	}
+	sum := func(td []stats.TaxonDist) float32 {
+		var res float32
+		for _, v := range td {
+			res += v.Percentage
+		}
+		return res
+	}
+
+	res := stats.New(hs, 0.5)
+	assert.Equal(10, len(res.Genera))
+	assert.NotEqual(float32(1), sum(res.Genera))
+
+	res = stats.New(hs, 0.5, stats.OptNormalizePercentages(true))
+	assert.Equal(float32(1), sum(res.Genera))
+	assert.Equal(float32(1), sum(res.Distribution(stats.Genus)))
+	assert.InDelta(0.1, res.Genera[0].Percentage, 0.000001)
+	assert.Equal(float32(1), sum(res.Kingdoms))
+	assert.Equal("Strigidae", res.MainTaxon.Name)
+}
+
+func TestOptStripAuthorship(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus|species"
+	hs := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo|Bubo bubo",
+			ranks, "N|CH2|V2|466|GQX|3DQQ|1"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo Duméril, 1805|"+
+			"Bubo scandiacus (Linnaeus, 1758)", ranks, "N|CH2|V2|466|GQX|3DQQ|2"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Strix|Strix aluco",
+			ranks, "N|CH2|V2|466|GQX|6W7S|3"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Tytonidae|Tyto|Tyto alba",
+			ranks, "N|CH2|V2|466|GQW|6Y1F|4"),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal(4, len(res.Genera))
+	assert.Equal("", res.Genus.Name)
+
+	res = stats.New(hs, 0.5, stats.OptStripAuthorship(true))
+	assert.Equal(3, len(res.Genera))
+	assert.Equal("Bubo", res.Genus.Name)
+	assert.Equal(float32(0.5), res.GenusPercentage)
+	species := res.Distribution(stats.Species)
+	names := make([]string, len(species))
+	for i := range species {
+		names[i] = species[i].Name
+	}
+	assert.Contains(names, "Bubo scandiacus")
+
+	// names without a year are not changed
+	hs[1] = newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo (Bubo)",
+		"kingdom|phylum|class|order|family|genus", "N|CH2|V2|466|GQX|3DQQ")
+	res = stats.New(hs, 0.5, stats.OptStripAuthorship(true))
+	assert.Equal(4, len(res.Genera))
+}
+
+func TestOptNormalizeUnicode(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus|species"
+	composed := "Bubo Dum\u00e9ril"
+	decomposed := "Bubo Dume\u0301ril"
+	assert.NotEqual(composed, decomposed)
+	hs := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|"+composed+
+			"|Bubo bubo", ranks, "N|CH2|V2|466|GQX|3DQQ|1"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|"+composed+
+			"|Bubo scandiacus", ranks, "N|CH2|V2|466|GQX|3DQQ|2"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|"+decomposed+
+			"|Bubo virginianus", ranks, "N|CH2|V2|466|GQX|3DQQ|3"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|"+decomposed+
+			"|Bubo africanus", ranks, "N|CH2|V2|466|GQX|3DQQ|4"),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal(1, len(res.Genera))
+	assert.Equal(composed, res.Genus.Name)
+	assert.Equal(float32(1), res.GenusPercentage)
+	assert.Equal(composed, res.MainTaxon.Name)
+	assert.Equal(decomposed, hs[2].Taxons()[5].Name)
+
+	res = stats.New(hs, 0.5, stats.OptNormalizeUnicode(false))
+	assert.Equal(2, len(res.Genera))
+	assert.Equal("Strigidae", res.MainTaxon.Name)
+}
+
+func TestOptRequireRank(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	exp := stats.New(hs, 0.5)
+
+	// add copies of some of the names without their families
+	gappy := hs
+	for _, h := range hs[:50] {
+		var names, ranks, ids []string
+		for _, v := range h.Taxons() {
+			if v.WithResolvedRank().Rank == stats.Family {
+				continue
+			}
+			names = append(names, v.Name)
+			ranks = append(ranks, v.RankStr)
+			ids = append(ids, v.ID)
+		}
+		gappy = append(gappy, newHry(
+			strings.Join(names, "|"),
+			strings.Join(ranks, "|"),
+			strings.Join(ids, "|"),
+		))
+	}
+	all := stats.New(gappy, 0.5)
+	assert.Greater(all.NamesNum, exp.NamesNum)
+
+	res := stats.New(gappy, 0.5, stats.OptRequireRank(stats.Family))
+	assert.Equal(exp.NamesNum, res.NamesNum)
+	assert.Equal(all.DroppedNames+all.NamesNum-exp.NamesNum, res.DroppedNames)
+	assert.Equal(exp.MainTaxon, res.MainTaxon)
+	assert.Equal(exp.FamilyPercentage, res.FamilyPercentage)
+	assert.Greater(res.FamilyPercentage, all.FamilyPercentage)
+	assert.Equal(exp.Distribution(stats.Family), res.Distribution(stats.Family))
+
+	var codes []stats.WarningCode
+	for _, v := range res.Warnings {
+		codes = append(codes, v.Code)
+	}
+	assert.Contains(codes, stats.WarnMissingRank)
+
+	res = stats.New(gappy, 0.5, stats.OptRequireRank(stats.Unknown))
+	assert.True(all.Equal(res))
+}
+
+func TestOptRankHeuristics(t *testing.T) {
+	assert := assert.New(t)
+	hs := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Bubo|Bubo bubo", "kingdom|phylum|class||",
+			"N|CH2|V2|3DQQ|NKSD"),
+		newHry("Animalia|Chordata|Aves|Strix", "kingdom|phylum|class|",
+			"N|CH2|V2|6W7S"),
+		newHry("Animalia|Chordata|Aves|Strigidae", "kingdom|phylum|class|family",
+			"N|CH2|V2|GQX"),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal(stats.ReasonNoNames, res.EmptyReason)
+
+	res = stats.New(hs, 0.5, stats.OptRankHeuristics(true))
+	assert.Equal(2, res.NamesNum)
+	species := res.Distribution(stats.Species)
+	assert.Equal(1, len(species))
+	assert.Equal("Bubo bubo", species[0].Name)
+	genera := res.Distribution(stats.Genus)
+	assert.Equal(1, len(genera))
+	assert.Equal("Strix", genera[0].Name)
+	assert.Equal(float32(1), res.ClassPercentage)
+}
+
+func TestOptBotanicalRankNames(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus"
+	plants := []stats.Hierarchy{
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Lamiales|Plantaginaceae|Plantago",
+			ranks, "P|TP|MG|LM|PL|PT"),
+		newHry("Plantae|Tracheophyta|Liliopsida|Poales|Poaceae|Poa",
+			ranks, "P|TP|LL|PO|PC|PA"),
+	}
+	res := stats.New(plants, 0.5)
+	assert.Equal("phylum", res.Phylum.RankStr)
+	assert.Equal("Tracheophyta", res.MainTaxon.Name)
+
+	res = stats.New(plants, 0.5, stats.OptBotanicalRankNames(true))
+	assert.Equal("Tracheophyta", res.Phylum.Name)
+	assert.Equal(stats.Phylum, res.Phylum.Rank)
+	assert.Equal("division", res.Phylum.RankStr)
+	assert.Equal("division", res.MainTaxon.RankStr)
+	assert.Equal(stats.Phylum, stats.NewRank(res.Phylum.RankStr))
+	assert.Equal("phylum", stats.Phylum.String())
+
+	res = stats.New(testData(t), 0.5, stats.OptBotanicalRankNames(true))
+	assert.Equal("phylum", res.Phylum.RankStr)
+}
+
+func TestOptSkipMinorRanks(t *testing.T) {
+	assert := assert.New(t)
+	inputs := [][]stats.Hierarchy{
+		taxons2(t, "reptiles.csv"),
+		testData(t),
+		deepHierarchies(300),
+	}
+	for _, hs := range inputs {
+		exp := stats.New(hs, 0.5)
+		res := stats.New(hs, 0.5, stats.OptSkipMinorRanks(true))
+		assert.Equal(exp.NamesNum, res.NamesNum)
+		assert.Equal(exp.MainTaxon, res.MainTaxon)
+		assert.Equal(exp.MainTaxonPercentage, res.MainTaxonPercentage)
+		assert.Equal(exp.Kingdoms, res.Kingdoms)
+		assert.Equal(exp.Genera, res.Genera)
+		for _, r := range []stats.Rank{
+			stats.Kingdom, stats.Phylum, stats.Class, stats.Order,
+			stats.Family, stats.Genus, stats.Species,
+		} {
+			txn, pcent := exp.Prevalent(r)
+			resTxn, resPcent := res.Prevalent(r)
+			assert.Equal(txn, resTxn, r.String())
+			assert.Equal(pcent, resPcent, r.String())
+			assert.Equal(exp.Distribution(r), res.Distribution(r), r.String())
+		}
+	}
+
+	hs := deepHierarchies(10)
+	res := stats.New(hs, 0.5, stats.OptSkipMinorRanks(true))
+	assert.NotNil(stats.New(hs, 0.5).Distribution(stats.SubFamily))
+	assert.Nil(res.Distribution(stats.SubFamily))
+
+	assert.True(stats.SubFamily.IsMinor())
+	assert.True(stats.SuperKingdom.IsMinor())
+	assert.False(stats.Family.IsMinor())
+	assert.False(stats.Unknown.IsMinor())
+}
+
+func BenchmarkOptSkipMinorRanks(b *testing.B) {
+	hs := deepHierarchies(1000)
+	b.Run("AllRanks", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = stats.New(hs, 0.5)
+		}
+	})
+	b.Run("SkipMinorRanks", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = stats.New(hs, 0.5, stats.OptSkipMinorRanks(true))
+		}
+	})
+}
+
+// deepHierarchies creates n hierarchies that contain all ranks from
+// empire to subspecies.
+func deepHierarchies(n int) []stats.Hierarchy {
+	var ranks []stats.Rank
+	for _, r := range stats.Ranks() {
+		if r.AtLeast(stats.SubSpecies) {
+			ranks = append(ranks, r)
+		}
+	}
+	res := make([]stats.Hierarchy, n)
+	for i := range res {
+		names := make([]string, len(ranks))
+		rankStrs := make([]string, len(ranks))
+		for j, r := range ranks {
+			names[j] = fmt.Sprintf("%s%d", r.String(), i*j/n)
+			rankStrs[j] = r.String()
+		}
+		res[i] = newHry(
+			strings.Join(names, "|"),
+			strings.Join(rankStrs, "|"),
+			strings.Join(names, "|"),
+		)
+	}
+	return res
+}
+
+// captureHandler saves all log records for later inspection.
+type captureHandler struct {
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *captureHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+type sourced struct {
+	stats.Hierarchy
+	source string
+}
+
+func (s sourced) Source() string {
+	return s.source
+}
+
+// sourcedGroup returns molluscs from the "big" source and 30 squamates
+// from the "small" source.
+func sourcedGroup(t *testing.T) []stats.Hierarchy {
+	var res []stats.Hierarchy
+	for _, h := range testData(t) {
+		res = append(res, sourced{h, "big"})
+	}
+	var reptiles int
+	for _, h := range taxons2(t, "reptiles.csv") {
+		if reptiles == 30 {
+			break
+		}
+		for _, v := range h.Taxons() {
+			if v.Name == "Squamata" {
+				res = append(res, sourced{h, "small"})
+				reptiles++
+				break
+			}
+		}
+	}
+	return res
+}
+
+func TestOptSourceWeights(t *testing.T) {
+	assert := assert.New(t)
+	hs := sourcedGroup(t)
+	res := stats.New(hs, 0.5)
+	assert.Equal("Mollusca", res.MainTaxon.Name)
+	same := stats.New(hs, 0.5, stats.OptSourceWeights(map[string]float32{}))
+	assert.True(res.Equal(same))
+
+	weights := map[string]float32{"big": 0.2}
+	res = stats.New(hs, 0.5, stats.OptSourceWeights(weights))
+	assert.Equal("Amphisbaenidae", res.MainTaxon.Name)
+	assert.Equal(99, res.NamesNum)
+	dist := res.Distribution(stats.Phylum)
+	assert.Equal("Chordata", dist[0].Name)
+	assert.Equal(30, dist[0].NamesNum)
+	assert.InDelta(30/43.8, dist[0].Percentage, 0.001)
+}
+
+func TestOptSkipPlaceholders(t *testing.T) {
+	assert := assert.New(t)
+	var hs []stats.Hierarchy
+	for _, h := range testData(t) {
+		var names, ranks, ids []string
+		for _, v := range h.Taxons() {
+			if v.Name == "Muricidae" {
+				v.Name, v.ID = "Incertae sedis", "is"
+			}
+			names = append(names, v.Name)
+			ranks = append(ranks, v.RankStr)
+			ids = append(ids, v.ID)
+		}
+		hs = append(hs, newHry(
+			strings.Join(names, "|"),
+			strings.Join(ranks, "|"),
+			strings.Join(ids, "|"),
+		))
+	}
+
+	exp := stats.New(testData(t), 0.5)
+	res := stats.New(hs, 0.5)
+	assert.Equal(exp.NamesNum, res.NamesNum)
+	assert.Equal(exp.MainTaxon, res.MainTaxon)
+	dist := res.Distribution(stats.Family)
+	assert.Equal(len(exp.Distribution(stats.Family))-1, len(dist))
+	for _, v := range dist {
+		assert.NotEqual("Incertae sedis", v.Name)
+	}
+	assert.Less(res.FamilyPercentage, exp.FamilyPercentage)
+
+	res = stats.New(hs, 0.5, stats.OptSkipPlaceholders(false))
+	assert.Equal("Incertae sedis", res.Distribution(stats.Family)[0].Name)
+
+	res = stats.New(testData(t), 0.5, stats.OptPlaceholders([]string{"MURICIDAE"}))
+	for _, v := range res.Distribution(stats.Family) {
+		assert.NotEqual("Muricidae", v.Name)
+	}
+}
@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// distLine is one line of the ND-JSON output of distributions.
+type distLine struct {
+	Rank       string  `json:"rank"`
+	Name       string  `json:"name"`
+	ID         string  `json:"id"`
+	NamesNum   int     `json:"namesNum"`
+	Percentage float32 `json:"percentage"`
+}
+
+// WriteNDJSON writes distributions of names for all ranks with data as
+// newline-delimited JSON. Every line contains one taxon with its rank,
+// name, ID, number of names and percentage. Ranks go from the highest to
+// the lowest, taxons of a rank are ordered and have percentages the same
+// way as in Distribution. Taxons without a known rank are not included.
+func WriteNDJSON(w io.Writer, s Stats) error {
+	enc := json.NewEncoder(w)
+	for _, r := range Ranks() {
+		if r.AtMost(Unknown) {
+			continue
+		}
+		for _, v := range s.Distribution(r) {
+			line := distLine{
+				Rank:       r.String(),
+				Name:       v.Name,
+				ID:         v.ID,
+				NamesNum:   v.NamesNum,
+				Percentage: v.Percentage,
+			}
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TableHeader contains column names of the table returned by Stats.Table.
+var TableHeader = []string{
+	"rank", "taxon_id", "taxon_name", "count", "percentage",
+}
+
+// Table returns distributions of names for all ranks with data as a table
+// in a long format, ready to be written as CSV. The first row is
+// TableHeader. Rows are ordered the same way as in WriteNDJSON: by ranks
+// from the highest to the lowest, and then as in Distribution. Taxons
+// without a known rank are not included.
+func (s Stats) Table() [][]string {
+	res := [][]string{append([]string(nil), TableHeader...)}
+	for _, r := range Ranks() {
+		if r.AtMost(Unknown) {
+			continue
+		}
+		for _, v := range s.Distribution(r) {
+			res = append(res, []string{
+				r.String(),
+				v.ID,
+				v.Name,
+				strconv.Itoa(v.NamesNum),
+				strconv.FormatFloat(float64(v.Percentage), 'f', -1, 32),
+			})
+		}
+	}
+	return res
+}
+
+// WriteDOT writes a merged classification tree of all hierarchies in
+// Graphviz DOT format. Nodes represent taxons and are labeled with
+// the taxon name and the number of hierarchies that contain it. Edges
+// connect parent and child taxons. Nodes are identified by taxon IDs,
+// or by names and ranks if IDs are empty.
+func WriteDOT(w io.Writer, h []Hierarchy) error {
+	var nodes []Taxon
+	counts := make(map[Taxon]int)
+	edges := make(map[[2]Taxon]struct{})
+	var edgesOrder [][2]Taxon
+	for i := range h {
+		taxons := make([]Taxon, 0, len(h[i].Taxons()))
+		for _, v := range h[i].Taxons() {
+			taxons = append(taxons, v.WithResolvedRank())
+		}
+		if !isSorted(taxons) {
+			SortTaxons(taxons)
+		}
+		for ii := range taxons {
+			if _, ok := counts[taxons[ii]]; !ok {
+				nodes = append(nodes, taxons[ii])
+			}
+			counts[taxons[ii]]++
+			if ii == 0 {
+				continue
+			}
+			edge := [2]Taxon{taxons[ii-1], taxons[ii]}
+			if _, ok := edges[edge]; !ok {
+				edges[edge] = struct{}{}
+				edgesOrder = append(edgesOrder, edge)
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, "digraph taxonomy {\n"); err != nil {
+		return err
+	}
+	for _, v := range nodes {
+		label := fmt.Sprintf("%s (%d)", v.Name, counts[v])
+		_, err := fmt.Fprintf(w, "  %s [label=%s];\n", dotID(v), strconv.Quote(label))
+		if err != nil {
+			return err
+		}
+	}
+	for _, v := range edgesOrder {
+		_, err := fmt.Fprintf(w, "  %s -> %s;\n", dotID(v[0]), dotID(v[1]))
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+// dotID returns a quoted DOT identifier of a taxon.
+func dotID(t Taxon) string {
+	if t.ID != "" {
+		return strconv.Quote(t.ID)
+	}
+	return strconv.Quote(t.Name + "|" + t.Rank.String())
+}
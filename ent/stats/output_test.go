@@ -0,0 +1,151 @@
+package stats_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res := stats.New(hs, 0.5)
+
+	distinct := make(map[stats.Taxon]struct{})
+	for i := range hs {
+		for _, v := range hs[i].Taxons() {
+			v = v.WithResolvedRank()
+			if v.Rank.AtMost(stats.Unknown) {
+				continue
+			}
+			distinct[v] = struct{}{}
+		}
+	}
+
+	var buf bytes.Buffer
+	err := stats.WriteNDJSON(&buf, res)
+	assert.Nil(err)
+
+	var lines int
+	var gastropoda bool
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		lines++
+		var line struct {
+			Rank       string  `json:"rank"`
+			Name       string  `json:"name"`
+			ID         string  `json:"id"`
+			NamesNum   int     `json:"namesNum"`
+			Percentage float32 `json:"percentage"`
+		}
+		err = json.Unmarshal(sc.Bytes(), &line)
+		assert.Nil(err)
+		if lines == 1 {
+			assert.Equal("kingdom", line.Rank)
+			assert.Equal("Animalia", line.Name)
+			assert.Equal(69, line.NamesNum)
+		}
+		if line.Name == "Gastropoda" {
+			gastropoda = true
+			assert.Equal("class", line.Rank)
+			assert.Equal("7NF3Y", line.ID)
+			assert.Equal(res.ClassPercentage, line.Percentage)
+		}
+	}
+	assert.True(gastropoda)
+	assert.Equal(len(distinct), lines)
+}
+
+func TestTable(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	tbl := res.Table()
+	assert.Equal(
+		[]string{"rank", "taxon_id", "taxon_name", "count", "percentage"},
+		tbl[0],
+	)
+	var classRows [][]string
+	for _, row := range tbl[1:] {
+		assert.Equal(5, len(row))
+		if row[0] == "class" {
+			classRows = append(classRows, row)
+		}
+	}
+	assert.Greater(len(classRows), 1)
+	assert.Equal("Gastropoda", classRows[0][2])
+	assert.Equal("38", classRows[0][3])
+	assert.Equal(strconv.FormatFloat(float64(res.ClassPercentage), 'f', -1, 32),
+		classRows[0][4])
+	assert.Equal("kingdom", tbl[1][0])
+
+	assert.Equal(1, len(stats.Stats{}.Table()))
+}
+
+func TestOutputSourceWeights(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus"
+	var hs []stats.Hierarchy
+	for i := 0; i < 3; i++ {
+		h := newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo",
+			ranks, "N|CH2|V2|466|GQX|3DQQ")
+		hs = append(hs, sourced{h, "big"})
+	}
+	hs = append(hs, newHry(
+		"Plantae|Tracheophyta|Magnoliopsida|Lamiales|Plantaginaceae|Plantago",
+		ranks, "P|TP|MG|LM|PL|PT"))
+	res := stats.New(hs, 0.5,
+		stats.OptSourceWeights(map[string]float32{"big": 0.1}))
+	exp := res.Distribution(stats.Kingdom)
+	assert.Equal("Plantae", exp[0].Name)
+	assert.InDelta(1/1.3, exp[0].Percentage, 0.0001)
+
+	var buf bytes.Buffer
+	assert.Nil(stats.WriteNDJSON(&buf, res))
+	sc := bufio.NewScanner(&buf)
+	for i := range exp {
+		assert.True(sc.Scan())
+		var line struct {
+			Rank       string  `json:"rank"`
+			Name       string  `json:"name"`
+			NamesNum   int     `json:"namesNum"`
+			Percentage float32 `json:"percentage"`
+		}
+		assert.Nil(json.Unmarshal(sc.Bytes(), &line))
+		assert.Equal("kingdom", line.Rank)
+		assert.Equal(exp[i].Name, line.Name)
+		assert.Equal(exp[i].NamesNum, line.NamesNum)
+		assert.Equal(exp[i].Percentage, line.Percentage)
+	}
+
+	tbl := res.Table()
+	for i := range exp {
+		row := tbl[i+1]
+		assert.Equal("kingdom", row[0])
+		assert.Equal(exp[i].Name, row[2])
+		assert.Equal(strconv.Itoa(exp[i].NamesNum), row[3])
+		assert.Equal(strconv.FormatFloat(float64(exp[i].Percentage), 'f', -1, 32),
+			row[4])
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	var buf bytes.Buffer
+	err := stats.WriteDOT(&buf, hs)
+	assert.Nil(err)
+	dot := buf.String()
+	assert.True(strings.HasPrefix(dot, "digraph taxonomy {\n"))
+	assert.True(strings.HasSuffix(dot, "}\n"))
+	assert.Contains(dot, `  "5T6MX" [label="Biota (69)"];`)
+	assert.Contains(dot, `  "N" [label="Animalia (69)"];`)
+	assert.Contains(dot, `  "5T6MX" -> "N";`)
+	assert.Contains(dot, `  "M2L" -> "7NF3Y";`)
+	assert.Equal(1, strings.Count(dot, `"N" -> "M2L"`))
+}
@@ -11,3 +11,189 @@ func TestRank(t *testing.T) {
 	assert.True(t, stats.Empire > stats.Kingdom)
 	assert.True(t, stats.Class > stats.SubClass)
 }
+
+func TestRankCompare(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(stats.Empire.AtLeast(stats.Kingdom))
+	assert.False(stats.Kingdom.AtLeast(stats.Empire))
+	assert.True(stats.Class.AtLeast(stats.SubClass))
+	assert.True(stats.Genus.AtLeast(stats.Genus))
+	assert.True(stats.SubClass.AtMost(stats.Class))
+	assert.True(stats.Genus.AtMost(stats.Genus))
+	assert.False(stats.Family.AtMost(stats.Genus))
+	assert.True(stats.Unknown.AtMost(stats.SubSpecies))
+
+	assert.True(stats.Species.Between(stats.SubSpecies, stats.Genus))
+	assert.True(stats.Genus.Between(stats.SubSpecies, stats.Genus))
+	assert.False(stats.Family.Between(stats.SubSpecies, stats.Genus))
+	assert.False(stats.Unknown.Between(stats.SubSpecies, stats.Genus))
+}
+
+func TestRanks(t *testing.T) {
+	assert := assert.New(t)
+	rs := stats.Ranks()
+	assert.Equal(len(stats.RankStr), len(rs))
+	assert.Equal(stats.Empire, rs[0])
+	assert.Equal(stats.Empty, rs[len(rs)-1])
+	for i, v := range rs {
+		assert.Equal(i, v.Index())
+	}
+}
+
+func TestRankCode(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(len(stats.RankStr), len(stats.RankCode))
+	for _, r := range stats.Ranks() {
+		assert.Equal(r, stats.RankFromCode(r.Code()), r.String())
+	}
+	assert.Equal(10, stats.Kingdom.Code())
+	assert.Equal(stats.Genus, stats.RankFromCode(180))
+	assert.Equal(stats.SuperPhylum, stats.RankFromCode(27))
+	assert.Equal(stats.Unknown, stats.RankFromCode(25))
+	assert.Equal(stats.Unknown, stats.RankFromCode(210))
+	assert.Equal(stats.Unknown, stats.RankFromCode(9999))
+}
+
+func TestRankDepth(t *testing.T) {
+	tests := []struct {
+		rank  stats.Rank
+		depth int
+	}{
+		{stats.Empire, -1},
+		{stats.SuperKingdom, -1},
+		{stats.Kingdom, 0},
+		{stats.SubKingdom, 0},
+		{stats.SuperPhylum, 1},
+		{stats.Phylum, 1},
+		{stats.SubPhylum, 1},
+		{stats.SuperClass, 2},
+		{stats.Class, 2},
+		{stats.SubClass, 2},
+		{stats.InfraClass, 2},
+		{stats.SubTerClass, 2},
+		{stats.ParvClass, 2},
+		{stats.SuperOrder, 3},
+		{stats.Order, 3},
+		{stats.SubOrder, 3},
+		{stats.InfraOrder, 3},
+		{stats.SuperFamily, 4},
+		{stats.Family, 4},
+		{stats.SubFamily, 4},
+		{stats.InfraFamily, 4},
+		{stats.Tribe, 4},
+		{stats.SubTribe, 4},
+		{stats.SuperGenus, 5},
+		{stats.Genus, 5},
+		{stats.SubGenus, 5},
+		{stats.SuperSpecies, 6},
+		{stats.Species, 6},
+		{stats.SubSpecies, 6},
+		{stats.Unknown, -1},
+		{stats.Empty, -1},
+	}
+	assert.Equal(t, len(stats.Ranks()), len(tests))
+	for _, v := range tests {
+		assert.Equal(t, v.depth, v.rank.Depth(), v.rank.String())
+	}
+}
+
+func TestRankSet(t *testing.T) {
+	assert := assert.New(t)
+	var s stats.RankSet
+	assert.Equal(0, s.Len())
+	assert.False(s.Has(stats.Empty))
+	s.Add(stats.Genus)
+	s.Add(stats.Kingdom)
+	s.Add(stats.Genus)
+	s.Add(stats.Rank(100))
+	assert.Equal(2, s.Len())
+	assert.True(s.Has(stats.Genus))
+	assert.False(s.Has(stats.Family))
+	assert.False(s.Has(stats.Rank(100)))
+	assert.Equal([]stats.Rank{stats.Kingdom, stats.Genus}, s.Ranks())
+
+	var o stats.RankSet
+	o.Add(stats.Genus)
+	o.Add(stats.Family)
+	assert.Equal([]stats.Rank{stats.Genus}, s.Intersect(o).Ranks())
+	assert.Equal(
+		[]stats.Rank{stats.Kingdom, stats.Family, stats.Genus},
+		s.Union(o).Ranks(),
+	)
+	s.Remove(stats.Genus)
+	assert.Equal([]stats.Rank{stats.Kingdom}, s.Ranks())
+
+	hs := fiftyFifty()
+	puma := stats.RanksOf(hs[1])
+	assert.Equal(11, puma.Len())
+	for _, r := range []stats.Rank{
+		stats.Kingdom, stats.SubClass, stats.InfraClass, stats.SubOrder,
+		stats.SubFamily, stats.Species,
+	} {
+		assert.True(puma.Has(r), r.String())
+	}
+	assert.False(puma.Has(stats.Empty))
+	assert.False(puma.Has(stats.Tribe))
+
+	shared := puma.Intersect(stats.RanksOf(hs[3]))
+	assert.Equal([]stats.Rank{
+		stats.Kingdom, stats.Phylum, stats.Class, stats.Order, stats.Family,
+		stats.SubFamily, stats.Genus, stats.Species,
+	}, shared.Ranks())
+}
+
+func TestSortTaxons(t *testing.T) {
+	assert := assert.New(t)
+	txs := []stats.Taxon{
+		{Name: "Bubo bubo", RankStr: "species"},
+		{Name: "Bubo", RankStr: "genus"},
+		{Name: "Strigidae", Rank: stats.Family},
+		{Name: "Biota", RankStr: "unranked"},
+		{Name: "Aves", RankStr: "class"},
+		{Name: "Chordata", RankStr: "phylum"},
+		{Name: "Vertebrata", RankStr: "phylum"},
+		{Name: "Animalia", RankStr: "kingdom"},
+	}
+	stats.SortTaxons(txs)
+	names := make([]string, len(txs))
+	for i := range txs {
+		names[i] = txs[i].Name
+	}
+	assert.Equal([]string{
+		"Biota", "Animalia", "Chordata", "Vertebrata", "Aves", "Strigidae",
+		"Bubo", "Bubo bubo",
+	}, names)
+	assert.Equal(stats.Empty, txs[1].Rank)
+}
+
+func TestRankAliases(t *testing.T) {
+	assert := assert.New(t)
+	reg := stats.NewRankAliasRegistry()
+	_, ok := reg.Lookup("division")
+	assert.False(ok)
+	reg.Register("Division", stats.Phylum)
+	rank, ok := reg.Lookup("division")
+	assert.True(ok)
+	assert.Equal(stats.Phylum, rank)
+	reg.Register("genus", stats.Family)
+	rank, ok = reg.Lookup("Genus")
+	assert.True(ok)
+	assert.Equal(stats.Family, rank)
+	reg.Unregister("GENUS")
+	_, ok = reg.Lookup("genus")
+	assert.False(ok)
+
+	t.Cleanup(func() {
+		for _, v := range []string{"sectio", "division", "genus"} {
+			stats.RankAliases.Unregister(v)
+		}
+	})
+	assert.Equal(stats.Unknown, stats.NewRank("sectio"))
+	stats.RankAliases.Register("sectio", stats.SubGenus)
+	assert.Equal(stats.SubGenus, stats.NewRank("Sectio"))
+	stats.RankAliases.Register("division", stats.Phylum)
+	assert.Equal(stats.Phylum, stats.NewRank("division"))
+	// built-in ranks have priority
+	stats.RankAliases.Register("genus", stats.Family)
+	assert.Equal(stats.Genus, stats.NewRank("genus"))
+}
@@ -1,6 +1,10 @@
 package stats
 
-import "strings"
+import (
+	"sort"
+	"strings"
+	"sync"
+)
 
 // Rank represents a rank of a taxon.
 type Rank int
@@ -78,10 +82,68 @@ var RankStr = map[Rank]string{
 	Empire:       "empire",
 }
 
+// majorRanks are the main ranks of the taxonomic hierarchy from kingdom to
+// genus.
+var majorRanks = []Rank{Kingdom, Phylum, Class, Order, Family, Genus}
+
+// Ranks returns all ranks ordered from the highest (Empire) to the lowest
+// (Empty).
+func Ranks() []Rank {
+	res := make([]Rank, 0, len(RankStr))
+	for r := Empire; r >= Empty; r-- {
+		res = append(res, r)
+	}
+	return res
+}
+
 type rankData struct {
 	rank  Rank
 	total int
 	data  map[Taxon]int
+
+	// weights contains sums of weights of names for taxons. If it is nil,
+	// every name has weight 1.
+	weights     map[Taxon]float64
+	weightTotal float64
+
+	// sourced contains sums of source weights of names for taxons (see
+	// OptSourceWeights), sourcedNames is the sum of source weights of all
+	// names. If sourced is nil, percentages are calculated from the numbers
+	// of names.
+	sourced      map[Taxon]float64
+	sourcedNames float64
+}
+
+// count returns the number of names of a taxon, or the sum of their
+// source weights, if source weights are used.
+func (rd rankData) count(t Taxon) float64 {
+	if rd.sourced != nil {
+		return rd.sourced[t]
+	}
+	return float64(rd.data[t])
+}
+
+// share returns the percentage of names that belong to a taxon.
+func (rd rankData) share(t Taxon, namesNum int) float32 {
+	if rd.sourced != nil {
+		if rd.sourcedNames <= 0 {
+			return 0
+		}
+		return float32(rd.sourced[t] / rd.sourcedNames)
+	}
+	return float32(rd.data[t]) / float32(namesNum)
+}
+
+// weighted returns weights of taxons and their total.
+func (rd rankData) weighted() (map[Taxon]float64, float64) {
+	if rd.weights != nil {
+		return rd.weights, rd.weightTotal
+	}
+	res := make(map[Taxon]float64, len(rd.data))
+	for k, v := range rd.data {
+		res[k] = float64(v)
+	}
+	return res, float64(rd.total)
 }
 
 func ranksData() []rankData {
@@ -127,6 +189,71 @@ func (r Rank) Index() int {
 	return l - i - 1
 }
 
+// AtLeast returns true if the rank is the same as, or higher in the
+// taxonomic hierarchy than the given rank (for example Family.AtLeast(Genus)
+// is true). Empty and Unknown ranks are considered lower than any
+// other rank.
+func (r Rank) AtLeast(o Rank) bool {
+	return r >= o
+}
+
+// AtMost returns true if the rank is the same as, or lower in the
+// taxonomic hierarchy than the given rank (for example Genus.AtMost(Family)
+// is true). Empty and Unknown ranks are considered lower than any
+// other rank.
+func (r Rank) AtMost(o Rank) bool {
+	return r <= o
+}
+
+// Between returns true if the rank is located in the taxonomic hierarchy
+// between the lower (more specific) rank lo and the higher rank hi,
+// including the ranks themselves.
+func (r Rank) Between(lo, hi Rank) bool {
+	return r.AtLeast(lo) && r.AtMost(hi)
+}
+
+// Depth returns the number of steps from kingdom to the rank along
+// the main ladder of ranks: 0 for kingdom, 1 for phylum, 2 for class,
+// 3 for order, 4 for family, 5 for genus and 6 for species. Intermediate
+// ranks get the depth of the main rank they belong to, for example
+// superfamily and tribe are on the family level (4), subkingdom is on
+// the kingdom level (0). It returns -1 for ranks above kingdom and for
+// Empty and Unknown ranks.
+func (r Rank) Depth() int {
+	switch {
+	case r.Between(SubKingdom, Kingdom):
+		return 0
+	case r.Between(SubPhylum, SuperPhylum):
+		return 1
+	case r.Between(ParvClass, SuperClass):
+		return 2
+	case r.Between(InfraOrder, SuperOrder):
+		return 3
+	case r.Between(SubTribe, SuperFamily):
+		return 4
+	case r.Between(SubGenus, SuperGenus):
+		return 5
+	case r.Between(SubSpecies, SuperSpecies):
+		return 6
+	default:
+		return -1
+	}
+}
+
+// IsMinor returns true for intermediate ranks, such as subfamily,
+// superorder, infraclass or tribe. Kingdom, phylum, class, order, family,
+// genus, species and empire are not minor, as well as Empty and Unknown
+// ranks.
+func (r Rank) IsMinor() bool {
+	switch r {
+	case Empty, Unknown, Empire, Kingdom, Phylum, Class, Order, Family,
+		Genus, Species:
+		return false
+	default:
+		return true
+	}
+}
+
 // StrRank conversts a rank string to Rank type.
 var StrRank = func() map[string]Rank {
 	res := make(map[string]Rank)
@@ -148,9 +275,116 @@ func NewRank(s string) Rank {
 	if rank, ok := StrRank[s]; ok {
 		return rank
 	}
+	if rank, ok := RankAliases.Lookup(s); ok {
+		return rank
+	}
 	return Unknown
 }
 
+// RankCode maps ranks to their numeric codes. Ranks known to ITIS have
+// their ITIS rank ids (kingdom is 10, superphylum is 27, phylum/division is
+// 30, class is 60, order is 100, family is 140, genus is 180, species is
+// 220). Ranks ITIS does not use (empire, superkingdom, subterclass,
+// parvclass, infrafamily, supergenus and superspecies) have codes specific
+// to this package, chosen from numbers ITIS does not use. Empty rank has
+// code 0, Unknown rank has code -1.
+var RankCode = map[Rank]int{
+	Unknown:      -1,
+	Empty:        0,
+	Empire:       1,
+	SuperKingdom: 5,
+	Kingdom:      10,
+	SubKingdom:   20,
+	SuperPhylum:  27,
+	Phylum:       30,
+	SubPhylum:    40,
+	SuperClass:   50,
+	Class:        60,
+	SubClass:     70,
+	InfraClass:   80,
+	SubTerClass:  83,
+	ParvClass:    86,
+	SuperOrder:   90,
+	Order:        100,
+	SubOrder:     110,
+	InfraOrder:   120,
+	SuperFamily:  130,
+	Family:       140,
+	SubFamily:    150,
+	InfraFamily:  155,
+	Tribe:        160,
+	SubTribe:     170,
+	SuperGenus:   175,
+	Genus:        180,
+	SubGenus:     190,
+	SuperSpecies: 215,
+	Species:      220,
+	SubSpecies:   230,
+}
+
+var codeRank = func() map[int]Rank {
+	res := make(map[int]Rank, len(RankCode))
+	for k, v := range RankCode {
+		res[v] = k
+	}
+	return res
+}()
+
+// RankFromCode creates Rank from a numeric code described in RankCode.
+// It returns Unknown for codes that are not in the table.
+func RankFromCode(code int) Rank {
+	if rank, ok := codeRank[code]; ok {
+		return rank
+	}
+	return Unknown
+}
+
+// Code returns the numeric code of a Rank according to RankCode.
+func (r Rank) Code() int {
+	if code, ok := RankCode[r]; ok {
+		return code
+	}
+	return RankCode[Unknown]
+}
+
+// RankAliases is a registry of rank aliases used by NewRank for strings
+// that are not recognized by the built-in table.
+var RankAliases = NewRankAliasRegistry()
+
+// RankAliasRegistry keeps aliases of ranks, for example "sectio" for
+// subgenus. It is safe for concurrent use.
+type RankAliasRegistry struct {
+	mu      sync.RWMutex
+	aliases map[string]Rank
+}
+
+// NewRankAliasRegistry creates an empty RankAliasRegistry.
+func NewRankAliasRegistry() *RankAliasRegistry {
+	return &RankAliasRegistry{aliases: make(map[string]Rank)}
+}
+
+// Register adds an alias for a rank. Aliases are case-insensitive.
+func (r *RankAliasRegistry) Register(alias string, rank Rank) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[strings.ToLower(alias)] = rank
+}
+
+// Unregister removes an alias. Aliases are case-insensitive.
+func (r *RankAliasRegistry) Unregister(alias string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.aliases, strings.ToLower(alias))
+}
+
+// Lookup returns a rank for an alias and true if the alias is registered.
+func (r *RankAliasRegistry) Lookup(alias string) (Rank, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rank, ok := r.aliases[strings.ToLower(alias)]
+	return rank, ok
+}
+
 // AddRank converts a RankStr to its Rank value and saves it in taxons.
 func AddRank(cs []Taxon) {
 	for i := range cs {
@@ -159,3 +393,39 @@ func AddRank(cs []Taxon) {
 		}
 	}
 }
+
+// SortTaxons sorts taxons from the highest to the lowest rank. Taxons with
+// Empty or Unknown ranks are placed before all ranked taxons. If Rank of
+// a taxon is Empty, the rank is determined from its RankStr. The sort is
+// stable, taxons of the same rank keep their order.
+func SortTaxons(taxons []Taxon) {
+	sort.SliceStable(taxons, func(i, j int) bool {
+		return sortRank(taxons[i]) > sortRank(taxons[j])
+	})
+}
+
+// isSorted checks if ranked taxons go from the highest to the lowest rank.
+// Taxons with Empty and Unknown ranks are ignored.
+func isSorted(taxons []Taxon) bool {
+	prev := Empire + 1
+	for i := range taxons {
+		r := taxons[i].WithResolvedRank().Rank
+		if r.AtMost(Unknown) {
+			continue
+		}
+		if r > prev {
+			return false
+		}
+		prev = r
+	}
+	return true
+}
+
+// sortRank returns a rank used for sorting, unranked taxons go first.
+func sortRank(t Taxon) Rank {
+	r := t.WithResolvedRank().Rank
+	if r.AtMost(Unknown) {
+		return Empire + 1
+	}
+	return r
+}
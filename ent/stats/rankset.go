@@ -0,0 +1,69 @@
+package stats
+
+import "math/bits"
+
+// RankSet is a set of ranks stored as a bitmask. The zero value is an
+// empty set.
+type RankSet uint64
+
+// RanksOf returns the set of ranks of the taxons of a hierarchy. Taxons
+// with Empty or Unknown ranks are ignored.
+func RanksOf(h Hierarchy) RankSet {
+	var res RankSet
+	for _, v := range h.Taxons() {
+		r := v.WithResolvedRank().Rank
+		if r.AtLeast(SubSpecies) {
+			res.Add(r)
+		}
+	}
+	return res
+}
+
+// Has checks if the set contains a rank.
+func (s RankSet) Has(r Rank) bool {
+	return r >= Empty && r <= Empire && s&(1<<uint(r)) != 0
+}
+
+// Add adds a rank to the set. Values outside of the known ranks are
+// ignored.
+func (s *RankSet) Add(r Rank) {
+	if r < Empty || r > Empire {
+		return
+	}
+	*s |= 1 << uint(r)
+}
+
+// Remove removes a rank from the set.
+func (s *RankSet) Remove(r Rank) {
+	if r < Empty || r > Empire {
+		return
+	}
+	*s &^= 1 << uint(r)
+}
+
+// Union returns ranks that belong to any of the two sets.
+func (s RankSet) Union(o RankSet) RankSet {
+	return s | o
+}
+
+// Intersect returns ranks that belong to both sets, for example ranks
+// shared by two hierarchies.
+func (s RankSet) Intersect(o RankSet) RankSet {
+	return s & o
+}
+
+// Len returns the number of ranks in the set.
+func (s RankSet) Len() int {
+	return bits.OnesCount64(uint64(s))
+}
+
+// Ranks returns ranks of the set ordered from the highest to the lowest.
+func (s RankSet) Ranks() []Rank {
+	res := make([]Rank, 0, s.Len())
+	for r := Empire; r >= Empty; r-- {
+		if s.Has(r) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
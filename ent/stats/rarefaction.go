@@ -0,0 +1,72 @@
+package stats
+
+import "math/rand"
+
+// rarefactionRuns is the number of random orders of names that are
+// averaged by Rarefaction.
+const rarefactionRuns = 20
+
+// RarePoint is a point of a rarefaction curve.
+type RarePoint struct {
+	// Size is the number of names in the subsample.
+	Size int
+
+	// Richness is the expected number of distinct taxons of the rank in
+	// a subsample of this size.
+	Richness float64
+}
+
+// Rarefaction calculates a rarefaction curve for the given rank: the
+// expected number of distinct taxons of the rank in random subsamples of
+// names of increasing size. Sizes grow in the given number of equal steps
+// up to NamesNum. Names are subsampled without replacement, every
+// subsample is a part of the next, larger one, and richness is averaged
+// over several random orders of names, so the curve never goes down.
+// Names without a taxon of the rank do not add to the richness. The seed
+// makes results reproducible. It returns nil if there are no names or
+// steps are less than 1.
+func (s Stats) Rarefaction(rank Rank, steps int, seed int64) []RarePoint {
+	n := len(s.members)
+	if n == 0 || steps < 1 {
+		return nil
+	}
+	if steps > n {
+		steps = n
+	}
+
+	taxons := make([]Taxon, n)
+	for i, m := range s.members {
+		for _, v := range m.taxons {
+			if v.Rank == rank {
+				taxons[i] = v
+				break
+			}
+		}
+	}
+
+	res := make([]RarePoint, steps)
+	for i := range res {
+		res[i].Size = (i + 1) * n / steps
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	seen := make(map[Taxon]struct{})
+	for run := 0; run < rarefactionRuns; run++ {
+		for k := range seen {
+			delete(seen, k)
+		}
+		var step int
+		for i, idx := range rnd.Perm(n) {
+			if !taxons[idx].IsZero() {
+				seen[taxons[idx]] = struct{}{}
+			}
+			if i+1 == res[step].Size {
+				res[step].Richness += float64(len(seen))
+				step++
+			}
+		}
+	}
+	for i := range res {
+		res[i].Richness /= rarefactionRuns
+	}
+	return res
+}
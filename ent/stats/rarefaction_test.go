@@ -0,0 +1,29 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRarefaction(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	curve := res.Rarefaction(stats.Family, 10, 42)
+	assert.Equal(10, len(curve))
+	for i := 1; i < len(curve); i++ {
+		assert.Greater(curve[i].Size, curve[i-1].Size)
+		assert.GreaterOrEqual(curve[i].Richness, curve[i-1].Richness)
+	}
+	last := curve[len(curve)-1]
+	assert.Equal(res.NamesNum, last.Size)
+	assert.Equal(float64(len(res.Distribution(stats.Family))), last.Richness)
+	assert.Less(curve[0].Richness, last.Richness)
+
+	assert.Equal(curve, res.Rarefaction(stats.Family, 10, 42))
+	molluscs := stats.New(testData(t), 0.5)
+	assert.Equal(1.0, molluscs.Rarefaction(stats.Kingdom, 3, 1)[0].Richness)
+	assert.Nil(res.Rarefaction(stats.Family, 0, 42))
+	assert.Nil(stats.Stats{}.Rarefaction(stats.Family, 10, 42))
+}
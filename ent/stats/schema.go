@@ -0,0 +1,123 @@
+package stats
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// schemaDefs are types that are described once in the "$defs" section of
+// the JSON Schema and referenced from other places.
+var schemaDefs = map[reflect.Type]string{
+	reflect.TypeOf(Taxon{}):         "Taxon",
+	reflect.TypeOf(TaxonDist{}):     "TaxonDist",
+	reflect.TypeOf(Warning{}):       "Warning",
+	reflect.TypeOf(Rank(0)):         "Rank",
+	reflect.TypeOf(EmptyReason(0)):  "EmptyReason",
+	reflect.TypeOf(WarningCode("")): "WarningCode",
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) document that describes
+// JSON representation of Stats. The schema is generated from JSON tags of
+// the types, so it stays in sync with the output of json.Marshal. Ranks
+// are encoded as integers (see Rank), slices and maps can be null.
+func JSONSchema() []byte {
+	defs := make(map[string]any, len(schemaDefs))
+	for t, name := range schemaDefs {
+		defs[name] = typeSchema(t, false)
+	}
+	defs["Rank"] = map[string]any{
+		"type":    "integer",
+		"minimum": int(Empty),
+		"maximum": int(Empire),
+	}
+	defs["EmptyReason"] = map[string]any{
+		"type": "integer",
+		"enum": []EmptyReason{ReasonNone, ReasonSingleName, ReasonNoNames},
+	}
+	defs["WarningCode"] = map[string]any{
+		"type": "string",
+		"enum": []WarningCode{
+			WarnDroppedNames, WarnUnknownRanks, WarnTie, WarnMultipleKingdoms,
+			WarnMissingRank, WarnNoNameData,
+		},
+	}
+
+	schema := typeSchema(reflect.TypeOf(Stats{}), false)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Stats"
+	schema["$defs"] = defs
+	res, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		// the schema consists only of maps, slices and simple values
+		panic(err)
+	}
+	return res
+}
+
+// typeSchema returns the JSON Schema of a type. If ref is true, types
+// from schemaDefs are given as references.
+func typeSchema(t reflect.Type, ref bool) map[string]any {
+	if name, ok := schemaDefs[t]; ok && ref {
+		return map[string]any{"$ref": "#/$defs/" + name}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{
+			"type":  []string{"array", "null"},
+			"items": typeSchema(t.Elem(), true),
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": typeSchema(t.Elem(), true),
+		}
+	case reflect.Struct:
+		props := make(map[string]any)
+		required := make([]string, 0, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, ok := jsonName(f)
+			if !ok {
+				continue
+			}
+			props[name] = typeSchema(f.Type, true)
+			required = append(required, name)
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]any{}
+	}
+}
+
+// jsonName returns the name of a struct field in JSON, and false if
+// the field is not encoded.
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
@@ -0,0 +1,39 @@
+package stats_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	assert := assert.New(t)
+	c := jsonschema.NewCompiler()
+	err := c.AddResource("stats.json", bytes.NewReader(stats.JSONSchema()))
+	assert.Nil(err)
+	schema, err := c.Compile("stats.json")
+	assert.Nil(err)
+
+	inputs := [][]stats.Hierarchy{
+		testData(t),
+		fiftyFifty(),
+		taxons2(t, "reptiles.csv"),
+		nil,
+	}
+	for _, hs := range inputs {
+		bs, err := json.Marshal(stats.New(hs, 0.5))
+		assert.Nil(err)
+		var v any
+		assert.Nil(json.Unmarshal(bs, &v))
+		assert.Nil(schema.Validate(v))
+	}
+
+	var v any
+	assert.Nil(json.Unmarshal([]byte(`{"namesNum": "many"}`), &v))
+	assert.NotNil(schema.Validate(v))
+	assert.Equal(stats.JSONSchema(), stats.JSONSchema())
+}
@@ -0,0 +1,59 @@
+package stats
+
+// Jaccard returns the Jaccard index of sets of taxons of the given rank
+// that are present in two groups of names. The index is the number of
+// taxons found in both groups divided by the number of taxons found in any
+// of them. It is 1 for groups with the same taxons and 0 for groups without
+// common taxons. Names qualify the same way as for New with default
+// options. If neither group has taxons of the rank, it returns 0.
+func Jaccard(a, b []Hierarchy, rank Rank) float64 {
+	ca, cb := rankCounts(a, rank), rankCounts(b, rank)
+	var common int
+	for k := range ca {
+		if _, ok := cb[k]; ok {
+			common++
+		}
+	}
+	union := len(ca) + len(cb) - common
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// BrayCurtis returns the Bray-Curtis dissimilarity of two groups of names,
+// calculated from the numbers of names of taxons of the given rank. It is
+// 0 for groups with the same numbers of names in every taxon and 1 for
+// groups without common taxons. Names qualify the same way as for New with default
+// options. If neither group has taxons of the rank, it returns 0.
+func BrayCurtis(a, b []Hierarchy, rank Rank) float64 {
+	ca, cb := rankCounts(a, rank), rankCounts(b, rank)
+	var common, total int
+	for k, v := range ca {
+		total += v
+		if vb, ok := cb[k]; ok {
+			common += min(v, vb)
+		}
+	}
+	for _, v := range cb {
+		total += v
+	}
+	if total == 0 {
+		return 0
+	}
+	return 1 - 2*float64(common)/float64(total)
+}
+
+// rankCounts returns the numbers of qualified names of every taxon of the
+// given rank.
+func rankCounts(h []Hierarchy, rank Rank) map[Taxon]int {
+	res := make(map[Taxon]int)
+	for _, cs := range extractTaxons(h, newOptions(nil)) {
+		for i := range cs {
+			if cs[i].Rank == rank {
+				res[cs[i]]++
+			}
+		}
+	}
+	return res
+}
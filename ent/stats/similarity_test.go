@@ -0,0 +1,43 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaccard(t *testing.T) {
+	assert := assert.New(t)
+	reptiles := taxons2(t, "reptiles.csv")
+	a, b := reptiles[:300], reptiles[200:]
+
+	// 39 and 32 families, 5 of them are in both groups
+	assert.Equal(39, len(stats.New(a, 0.5).Distribution(stats.Family)))
+	assert.Equal(32, len(stats.New(b, 0.5).Distribution(stats.Family)))
+	assert.InDelta(5.0/66, stats.Jaccard(a, b, stats.Family), 0.00001)
+	assert.Equal(stats.Jaccard(a, b, stats.Family),
+		stats.Jaccard(b, a, stats.Family))
+
+	assert.Equal(1.0, stats.Jaccard(a, a, stats.Family))
+	assert.Equal(0.0, stats.Jaccard(testData(t), fiftyFifty(), stats.Family))
+	assert.Equal(0.0, stats.Jaccard(nil, nil, stats.Family))
+	assert.Equal(0.0, stats.Jaccard(a, nil, stats.Family))
+}
+
+func TestBrayCurtis(t *testing.T) {
+	assert := assert.New(t)
+	reptiles := taxons2(t, "reptiles.csv")
+	a, b := reptiles[:300], reptiles[200:]
+
+	res := stats.BrayCurtis(a, b, stats.Family)
+	assert.Greater(res, 0.0)
+	assert.Less(res, 1.0)
+	assert.Equal(res, stats.BrayCurtis(b, a, stats.Family))
+	assert.Less(stats.BrayCurtis(a, b, stats.Order), res)
+
+	assert.Equal(0.0, stats.BrayCurtis(a, a, stats.Family))
+	assert.Equal(1.0, stats.BrayCurtis(testData(t), fiftyFifty(), stats.Family))
+	assert.Equal(1.0, stats.BrayCurtis(a, nil, stats.Family))
+	assert.Equal(0.0, stats.BrayCurtis(nil, nil, stats.Family))
+}
@@ -5,24 +5,66 @@
 // Catalogue of Life, finds distribution of names across Kingdoms and
 // finds a taxon that contains a given percentage (always a majority)
 // of scientific names of genera and lower.
+//
+// Results are deterministic: the same input and options always produce
+// the same Stats. Distributions (for example Kingdoms, Genera or
+// the result of Distribution) are sorted by percentage in descending order,
+// then by ID and by name. Ties between taxons are resolved by ID and then
+// by name.
 package stats
 
+import (
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+)
+
 // Taxon struct represents a particular taxon according to the Catalogue of
 // Life (CoL). It includes an ID from CoL, name of the taxon, and numerical and
 // string representation of the taxon's rank.
 type Taxon struct {
 	// ID is the Catalogue of Life ID for the taxon.
-	ID string
+	ID string `json:"id"`
 
 	// Name is the name of the taxon.
-	Name string
+	Name string `json:"name"`
 
 	// RankStr is a string representation of the taxon's rank.
-	RankStr string
+	RankStr string `json:"rankStr"`
 
 	// Rank represents taxon's rank via Rank type. Rank type is derived from
 	// int type.
-	Rank
+	Rank `json:"rank"`
+}
+
+// WithResolvedRank returns a copy of the taxon. If the taxon's Rank is
+// Empty, it is resolved from the RankStr field.
+func (t Taxon) WithResolvedRank() Taxon {
+	if t.Rank == Empty {
+		t.Rank = NewRank(t.RankStr)
+	}
+	return t
+}
+
+// ColBaseURL is the base URL used by ColURL. It can be changed to point
+// to a local instance of the Catalogue of Life.
+var ColBaseURL = "https://www.catalogueoflife.org/data/taxon/"
+
+// ColURL returns the URL of the taxon in the Catalogue of Life. It returns
+// an empty string if ID is empty.
+func (t Taxon) ColURL() string {
+	if t.ID == "" {
+		return ""
+	}
+	return ColBaseURL + t.ID
+}
+
+// IsZero returns true if the taxon does not contain data: its ID and name
+// are empty, and the rank is Empty or Unknown.
+func (t Taxon) IsZero() bool {
+	return t.ID == "" && t.Name == "" && t.Rank.AtMost(Unknown)
 }
 
 // Stats struct provides statistical data about a group of verified by the
@@ -35,73 +77,196 @@ type Stats struct {
 	// NamesNum is the number of names that are used for stats calculation.
 	// These names include names of a rank `genus` and lower,
 	// verified to the Catalogue of Life
-	NamesNum int
+	NamesNum int `json:"namesNum"`
+
+	// InputCount is the number of hierarchies given for the calculation.
+	InputCount int `json:"inputCount"`
+
+	// DroppedNames is the number of input hierarchies that were not used
+	// for the calculation, for example names higher than genus, or
+	// duplicate species. InputCount is always equal to NamesNum plus
+	// DroppedNames.
+	DroppedNames int `json:"droppedNames"`
+
+	// Kingdoms is the distribution of names across detected kingdoms,
+	// sorted by percentage in descending order. It is populated whenever
+	// names have kingdom data, even if there is no single prevalent kingdom.
+	Kingdoms []TaxonDist `json:"kingdoms"`
 
-	// Kingdoms is the distribution of names across detected kingdoms.
-	Kingdoms []TaxonDist
+	// Genera is the distribution of names across detected genera, sorted by
+	// percentage in descending order. It is populated whenever names have
+	// genus data, even if there is no prevalent genus.
+	Genera []TaxonDist `json:"genera"`
 
-	// Kingdom is the most prevalent kingdom in the group of names.
-	Kingdom Taxon
+	// Kingdom is the most prevalent kingdom in the group of names. If
+	// several kingdoms have the same number of names, the one with the
+	// smallest ID (then name) is used, and KingdomTie is true.
+	Kingdom Taxon `json:"kingdom"`
+
+	// KingdomTie is true if several kingdoms share the highest percentage
+	// of names. All of them are listed in CoDominant.
+	KingdomTie bool `json:"kingdomTie"`
 
 	// KingdomPercentage is a value between 0 and 1 representing the percentage
 	// of names located in the most prevalent kingdom.
-	KingdomPercentage float32
+	KingdomPercentage float32 `json:"kingdomPercentage"`
 
 	// Phylum is the most prevalent phylum in the group of names.
-	Phylum Taxon
+	Phylum Taxon `json:"phylum"`
 
 	// PhylumPercentage is a value between 0 and 1 representing the percentage
 	// of names located in the most prevalent phylum.
-	PhylumPercentage float32
+	PhylumPercentage float32 `json:"phylumPercentage"`
 
 	// Class is the most prevalent class in the group of names.
-	Class Taxon
+	Class Taxon `json:"class"`
 
 	// ClassPercentage is a value between 0 and 1 representing the percentage
 	// of names located in the most prevalent class.
-	ClassPercentage float32
+	ClassPercentage float32 `json:"classPercentage"`
 
 	// Order is the most prevalent order in the group of names.
-	Order Taxon
+	Order Taxon `json:"order"`
 
 	// OrderPercentage is a value between 0 and 1 representing the percentage
 	// of names located in the most prevalent order.
-	OrderPercentage float32
+	OrderPercentage float32 `json:"orderPercentage"`
 
 	// Family is the most prevalent family in the group of names.
-	Family Taxon
+	Family Taxon `json:"family"`
 
 	// FamilyPercentage is a value between 0 and 1 representing the percentage
 	// of names located in the most prevalent family.
-	FamilyPercentage float32
+	FamilyPercentage float32 `json:"familyPercentage"`
 
 	// Genus is the most prevalent genus in the group of names.
-	Genus Taxon
+	Genus Taxon `json:"genus"`
 
 	// GenusPercentage is a value between 0 and 1 representing the percentage
 	// of names located in the most prevalent Genus.
-	GenusPercentage float32
+	GenusPercentage float32 `json:"genusPercentage"`
+
+	// Species is the most prevalent species in the group of names. It is
+	// empty if several species share the highest number of names. The
+	// distribution of species is available via Distribution(Species).
+	Species Taxon `json:"species"`
+
+	// SpeciesPercentage is a value between 0 and 1 representing the
+	// percentage of names located in the most prevalent species.
+	SpeciesPercentage float32 `json:"speciesPercentage"`
 
 	// MainTaxon is the taxon that contains at least the percentage of names
 	// according to the MainTaxonThreshold
-	MainTaxon Taxon
+	MainTaxon Taxon `json:"mainTaxon"`
 
 	// MainTaxonPercentage is a value between 0 and 1 representing the
 	// percentage of names located in the MainTaxon.
-	MainTaxonPercentage float32
+	MainTaxonPercentage float32 `json:"mainTaxonPercentage"`
+
+	// MainTaxonIsComplete is true if MainTaxon contains all names.
+	MainTaxonIsComplete bool `json:"mainTaxonIsComplete"`
+
+	// NamesOutsideMainTaxon is the number of names that do not belong to the
+	// MainTaxon. It is 0 if MainTaxon was not found.
+	NamesOutsideMainTaxon int `json:"namesOutsideMainTaxon"`
+
+	// GenusResolutionRate is a value between 0 and 1 representing the
+	// fraction of names that have a taxon of the genus rank.
+	GenusResolutionRate float32 `json:"genusResolutionRate"`
+
+	// EmptyReason explains why Stats are empty. It is ReasonNone if
+	// the stats were calculated.
+	EmptyReason EmptyReason `json:"emptyReason"`
+
+	// Warnings contains problems found during the calculation, for example
+	// dropped names or ties. It is nil if there were no problems.
+	Warnings []Warning `json:"warnings"`
+
+	// CoDominant contains taxa that share the highest percentage of names
+	// for kingdom, phylum, class, order, family or genus. The prevalent taxon
+	// of such rank stays empty, except for kingdom (see KingdomTie). It is
+	// nil if there were no ties.
+	CoDominant map[Rank][]Taxon `json:"coDominant"`
+
+	// entropies contains Shannon entropy for every rank that had data.
+	entropies map[Rank]float64
+
+	// simpsons contains Simpson index for every rank that had data.
+	simpsons map[Rank]float64
+
+	// topPercentages contains the percentage of names of the most prevalent
+	// taxon for every rank (higher than Unknown) that had data.
+	topPercentages map[Rank]float32
+
+	// rankTotals contains the number of taxons found for every rank that
+	// had data.
+	rankTotals map[Rank]int
+
+	// resolved contains the number of names that have a taxon of a rank,
+	// for every rank, including ranks removed by OptMinRankSamples.
+	resolved map[Rank]int
+
+	// rankCounts contains the number of names for every taxon of every
+	// rank that had data.
+	rankCounts map[Rank]map[Taxon]int
+
+	// rankSourced contains sums of source weights of names for every taxon
+	// of every rank, if OptSourceWeights was used. sourcedNames is the sum
+	// of source weights of all names.
+	rankSourced  map[Rank]map[Taxon]float64
+	sourcedNames float64
+
+	// children contains the number of names for every child taxon of
+	// a parent taxon.
+	children map[Taxon]map[Taxon]int
+
+	// members contains qualified taxons of every counted name.
+	members []member
+
+	// threshold and opts are the settings used for the calculation.
+	threshold float32
+	opts      options
+
+	// normalized is true if distributions have to be normalized to sum
+	// up to 1 (see OptNormalizePercentages).
+	normalized bool
 }
 
+// EmptyReason explains why Stats do not contain data.
+type EmptyReason int
+
+const (
+	// ReasonNone means that Stats were calculated.
+	ReasonNone EmptyReason = iota
+
+	// ReasonSingleName means that only one name qualified for
+	// the calculation.
+	ReasonSingleName
+
+	// ReasonNoNames means that none of the names qualified for
+	// the calculation.
+	ReasonNoNames
+)
+
 // TaxonDist provides information how a group of names is distributed
 // across taxons of the same rank.
 type TaxonDist struct {
 	// NamesNum is the number of names found for this particular rank.
-	NamesNum int
+	NamesNum int `json:"namesNum"`
+
+	// ID is the Catalogue of Life ID of the taxon.
+	ID string `json:"id"`
 
 	// Name is the scientific name of the taxon.
-	Name string
+	Name string `json:"name"`
 
 	// Percentage is the percentage of names belonging to this taxon.
-	Percentage float32
+	Percentage float32 `json:"percentage"`
+
+	// CoverageShare is the fraction of names that have a taxon of this rank
+	// and belong to this taxon. It differs from Percentage when some names
+	// do not have this rank in their hierarchies.
+	CoverageShare float32 `json:"coverageShare"`
 }
 
 // New takes several hierarhies, a MainTaxon threshold value, and returns back
@@ -111,49 +276,279 @@ type TaxonDist struct {
 // is provided via threshold parameter.
 //
 // The algorithm assumes that all items belong to the same classification tree.
+// Taxons of the given hierarchies are not modified.
+//
+// Options can modify the way stats are calculated.
 func New(
 	h []Hierarchy,
 	threshold float32,
+	opts ...Option,
 ) Stats {
-	if threshold < 0.5 {
-		threshold = 0.5
+	a := NewAggregator(threshold, opts...)
+	for i := range h {
+		a.Add(h[i])
 	}
+	return a.Stats()
+}
 
-	// collect names that are genus or lower, no taxons are removed from
-	// the hierarchy.
-	taxons := extractTaxons(h)
-	if len(taxons) == 1 {
-		return Stats{}
+// NewWithError calculates stats the same way as New, but returns an error
+// instead of dropping invalid hierarchies. With OptStrictTree it returns
+// ErrInconsistentTree if any hierarchy contains more than one taxon
+// of the same rank.
+func NewWithError(
+	h []Hierarchy,
+	threshold float32,
+	opts ...Option,
+) (Stats, error) {
+	a := NewAggregator(threshold, opts...)
+	for i := range h {
+		a.Add(h[i])
+		if a.treeErr != nil {
+			return Stats{}, a.treeErr
+		}
+	}
+	return a.Stats(), nil
+}
+
+// NewFromChan calculates stats the same way as New, but receives
+// hierarchies from a channel until it is closed. It returns the context's
+// error if the context is canceled before the channel is closed.
+func NewFromChan(
+	ctx context.Context,
+	ch <-chan Hierarchy,
+	threshold float32,
+	opts ...Option,
+) (Stats, error) {
+	a := NewAggregator(threshold, opts...)
+	for {
+		select {
+		case <-ctx.Done():
+			return Stats{}, ctx.Err()
+		case h, ok := <-ch:
+			if !ok {
+				return a.Stats(), nil
+			}
+			a.Add(h)
+		}
 	}
-	namesNum := len(taxons)
+}
 
-	// get empty structure for ranks stats
-	ranks := ranksData()
-	// populate ranks
-	for _, cs := range taxons {
-		for i := range cs {
-			rankIdx := cs[i].Index()
-			ranks[rankIdx].data[cs[i]]++
-			ranks[rankIdx].total++
+// NewFromCounts calculates stats from already aggregated data, for example
+// from counts received from a database. The counts contain the number of
+// names for every taxon of every rank, namesNum is the number of names the
+// counts were collected from. The result is the same as New would return
+// for the hierarchies of these names, except that data of individual
+// names and of parent-child pairs are not available: ChildShares,
+// OutlierNames, Rarefaction and RankWeightedDiversity return empty results,
+// and DrillDown returns Stats without names. Such Stats have
+// a WarnNoNameData warning. Options that change qualification or names of
+// taxons (for example OptMinPoolRank or OptCanonicalizeKingdoms) are not
+// applied to the counts.
+func NewFromCounts(
+	counts map[Rank]map[Taxon]int,
+	namesNum int,
+	threshold float32,
+	opts ...Option,
+) Stats {
+	a := NewAggregator(threshold, opts...)
+	a.inputCount = namesNum
+	a.namesNum = namesNum
+	for r, cs := range counts {
+		if r < Empty || r > Empire {
+			continue
+		}
+		rd := &a.ranks[r.Index()]
+		for k, v := range cs {
+			if v <= 0 {
+				continue
+			}
+			k.Rank = r
+			rd.data[k] += v
+			rd.total += v
 		}
 	}
+	res := a.Stats()
+	if res.EmptyReason == ReasonNone {
+		res.addWarning(WarnNoNameData,
+			"stats of %d names were calculated from aggregated counts",
+			namesNum)
+	}
+	return res
+}
+
+// CalcInto calculates stats the same way as New, but writes the result
+// into dst, reusing its slices and maps. It allows to decrease memory
+// allocations, for example when Stats are kept in a sync.Pool. The previous
+// content of dst is discarded, and all slices and maps received from dst
+// before, including the ones of its copies (such as `saved := *dst`), are
+// invalidated. Use Clone to keep a result.
+func CalcInto(
+	dst *Stats,
+	h []Hierarchy,
+	threshold float32,
+	opts ...Option,
+) {
+	a := NewAggregator(threshold, opts...)
+	for i := range h {
+		a.Add(h[i])
+	}
+	a.StatsInto(dst)
+}
+
+// Reset removes all data from Stats. Slices are truncated and maps are
+// cleared, keeping their allocated memory for reuse. CoDominant becomes
+// nil, the same as in Stats without ties. Copies of Stats share
+// this memory, so fields of copies (for example Kingdoms), and results of
+// their methods, are invalidated by Reset as well. Use Clone to get Stats
+// that do not share memory.
+func (s *Stats) Reset() {
+	kingdoms := s.Kingdoms[:0]
+	genera := s.Genera[:0]
+	entropies := s.entropies
+	for k := range entropies {
+		delete(entropies, k)
+	}
+	simpsons := s.simpsons
+	for k := range simpsons {
+		delete(simpsons, k)
+	}
+	tops := s.topPercentages
+	for k := range tops {
+		delete(tops, k)
+	}
+	totals := s.rankTotals
+	for k := range totals {
+		delete(totals, k)
+	}
+	resolved := s.resolved
+	for k := range resolved {
+		delete(resolved, k)
+	}
+	counts := s.rankCounts
+	for k := range counts {
+		delete(counts, k)
+	}
+	children := s.children
+	for k := range children {
+		delete(children, k)
+	}
+	sourced := s.rankSourced
+	for k := range sourced {
+		delete(sourced, k)
+	}
+	members := s.members[:0]
+	*s = Stats{
+		Kingdoms:       kingdoms,
+		Genera:         genera,
+		entropies:      entropies,
+		simpsons:       simpsons,
+		topPercentages: tops,
+		rankTotals:     totals,
+		resolved:       resolved,
+		rankCounts:     counts,
+		children:       children,
+		rankSourced:    sourced,
+		members:        members,
+	}
+}
 
-	ranks = removeEmptyRanks(ranks)
-	res := calcStats(namesNum, ranks, threshold)
+// Clone returns a deep copy of Stats that does not share slices and maps
+// with the original, so it stays valid after Reset or CalcInto of
+// the original.
+func (s Stats) Clone() Stats {
+	res := s
+	res.Kingdoms = slices.Clone(s.Kingdoms)
+	res.Genera = slices.Clone(s.Genera)
+	res.Warnings = slices.Clone(s.Warnings)
+	if s.CoDominant != nil {
+		res.CoDominant = make(map[Rank][]Taxon, len(s.CoDominant))
+		for k, v := range s.CoDominant {
+			res.CoDominant[k] = slices.Clone(v)
+		}
+	}
+	res.entropies = maps.Clone(s.entropies)
+	res.simpsons = maps.Clone(s.simpsons)
+	res.topPercentages = maps.Clone(s.topPercentages)
+	res.rankTotals = maps.Clone(s.rankTotals)
+	res.resolved = maps.Clone(s.resolved)
+	if s.rankCounts != nil {
+		res.rankCounts = make(map[Rank]map[Taxon]int, len(s.rankCounts))
+		for k, v := range s.rankCounts {
+			res.rankCounts[k] = copyCounts(v)
+		}
+	}
+	if s.rankSourced != nil {
+		res.rankSourced = make(map[Rank]map[Taxon]float64, len(s.rankSourced))
+		for k, v := range s.rankSourced {
+			res.rankSourced[k] = maps.Clone(v)
+		}
+	}
+	if s.children != nil {
+		res.children = make(map[Taxon]map[Taxon]int, len(s.children))
+		for k, v := range s.children {
+			res.children[k] = copyCounts(v)
+		}
+	}
+	res.members = slices.Clone(s.members)
 	return res
 }
 
+// KingdomDist calculates only the distribution of names across kingdoms.
+// It uses the same rules for names' qualification and the same options as
+// New, so the result is equal to Kingdoms of Stats, but it skips
+// calculations for all other ranks. The result is sorted by percentage
+// in descending order, and by IDs and names for equal percentages.
+func KingdomDist(h []Hierarchy, opts ...Option) []TaxonDist {
+	// the threshold does not affect distributions
+	return NewLazy(h, 0.5, opts...).Distribution(Kingdom)
+}
+
+// calcStats writes calculated stats into res. The res is expected to be
+// empty (see Reset), its buffers are reused.
 func calcStats(
+	res *Stats,
 	namesNum int,
 	ranks []rankData,
 	threshold float32,
-) Stats {
-	res := Stats{
-		NamesNum: namesNum,
+	o options,
+) {
+	if namesNum == 0 {
+		res.EmptyReason = ReasonNoNames
+		return
+	}
+	res.NamesNum = namesNum
+	calcDiversity(res, ranks)
+	if res.topPercentages == nil {
+		res.topPercentages = make(map[Rank]float32)
 	}
+	if res.rankTotals == nil {
+		res.rankTotals = make(map[Rank]int, len(ranks))
+	}
+	if res.rankCounts == nil {
+		res.rankCounts = make(map[Rank]map[Taxon]int, len(ranks))
+	}
+	for i := range ranks {
+		res.rankTotals[ranks[i].rank] = ranks[i].total
+		res.rankCounts[ranks[i].rank] = copyCounts(ranks[i].data)
+		if ranks[i].sourced == nil {
+			continue
+		}
+		if res.rankSourced == nil {
+			res.rankSourced = make(map[Rank]map[Taxon]float64, len(ranks))
+		}
+		sourced := make(map[Taxon]float64, len(ranks[i].sourced))
+		for k, v := range ranks[i].sourced {
+			sourced[k] = v
+		}
+		res.rankSourced[ranks[i].rank] = sourced
+		res.sourcedNames = ranks[i].sourcedNames
+	}
+	res.GenusResolutionRate = res.ResolutionRate(Genus)
+	res.normalized = o.normalizePercentages
 	var txnDistr []TaxonDist
 	var mainTaxon Taxon
 	var txnPCent float32
+	var txnNamesNum int
 	var foundMainTaxon bool
 	l := len(ranks)
 
@@ -161,52 +556,397 @@ func calcStats(
 		var maxTx Taxon
 		var maxPcent float32
 		reverseIdx := l - 1 - idx
-		if ranks[reverseIdx].rank <= Unknown {
+		if ranks[reverseIdx].rank.AtMost(Unknown) {
 			continue
 		}
 		txn, pcent := maxTaxon(namesNum, ranks[reverseIdx])
+		res.topPercentages[ranks[reverseIdx].rank] = pcent
 		switch ranks[reverseIdx].rank {
 		case Kingdom, Phylum, Class, Order, Family, Genus:
-			txnDistr = getTaxDist(namesNum, ranks[reverseIdx])
+			var buf []TaxonDist
+			switch ranks[reverseIdx].rank {
+			case Kingdom:
+				buf = res.Kingdoms[:0]
+			case Genus:
+				buf = res.Genera[:0]
+			}
+			txnDistr = appendTaxDist(buf, namesNum, ranks[reverseIdx])
+			sortTaxDist(txnDistr)
+			if ranks[reverseIdx].rank == Kingdom && len(txnDistr) > 1 {
+				res.addWarning(WarnMultipleKingdoms,
+					"names belong to %d kingdoms", len(txnDistr))
+			}
+			if ranks[reverseIdx].rank == Genus && len(txnDistr) > 0 {
+				res.Genera = txnDistr
+			}
 
-			if isMaxTaxon(txnDistr, pcent) {
+			isMax := isMaxTaxon(txnDistr, pcent)
+			prevalentPcent := pcent
+			if o.normalizePercentages && ranks[reverseIdx].total == namesNum {
+				prevalentPcent = normalizeDist(txnDistr)
+			}
+
+			if isMax {
+				maxTx, maxPcent = txn, prevalentPcent
+			} else {
+				if res.CoDominant == nil {
+					res.CoDominant = make(map[Rank][]Taxon)
+				}
+				tied := coDominant(ranks[reverseIdx])
+				res.CoDominant[ranks[reverseIdx].rank] = tied
+				if ranks[reverseIdx].rank == Kingdom {
+					maxTx, maxPcent = tied[0], pcent
+					res.KingdomTie = true
+				} else {
+					res.addWarning(WarnTie,
+						"%d taxa share the highest percentage of %s rank",
+						len(tied), ranks[reverseIdx].rank)
+				}
+				if o.logger != nil {
+					o.logger.Debug(
+						"tie for the prevalent taxon",
+						"rank", ranks[reverseIdx].rank.String(),
+						"percentage", pcent,
+						"taxaNum", len(tied),
+					)
+				}
+			}
+		case Species:
+			rd := ranks[reverseIdx]
+			if isPlurality(rd, txn) {
 				maxTx, maxPcent = txn, pcent
+				if o.normalizePercentages && rd.total == namesNum {
+					maxPcent = normalizeDist(appendTaxDist(nil, namesNum, rd))
+				}
+			}
+		}
+
+		if !maxTx.IsZero() {
+			switch maxTx.Rank {
+			case Kingdom:
+				res.Kingdom = maxTx
+				res.KingdomPercentage = maxPcent
+				res.Kingdoms = txnDistr
+			case Phylum:
+				res.Phylum = maxTx
+				res.PhylumPercentage = maxPcent
+			case Class:
+				res.Class = maxTx
+				res.ClassPercentage = maxPcent
+			case Order:
+				res.Order = maxTx
+				res.OrderPercentage = maxPcent
+			case Family:
+				res.Family = maxTx
+				res.FamilyPercentage = maxPcent
+			case Genus:
+				res.Genus = maxTx
+				res.GenusPercentage = maxPcent
+			case Species:
+				res.Species = maxTx
+				res.SpeciesPercentage = maxPcent
 			}
 		}
 
-		switch maxTx.Rank {
-		case Kingdom:
-			res.Kingdom = maxTx
-			res.KingdomPercentage = maxPcent
-			res.Kingdoms = txnDistr
-		case Phylum:
-			res.Phylum = maxTx
-			res.PhylumPercentage = maxPcent
-		case Class:
-			res.Class = maxTx
-			res.ClassPercentage = maxPcent
-		case Order:
-			res.Order = maxTx
-			res.OrderPercentage = maxPcent
-		case Family:
-			res.Family = maxTx
-			res.FamilyPercentage = maxPcent
-		case Genus:
-			res.Genus = maxTx
-			res.GenusPercentage = maxPcent
-		}
-
-		if pcent > threshold && !foundMainTaxon {
+		if !foundMainTaxon &&
+			o.isMainTaxonRank(ranks[reverseIdx].rank) &&
+			isMainTaxon(ranks[reverseIdx], txn, pcent, threshold, o) {
 			mainTaxon = txn
 			txnPCent = pcent
+			txnNamesNum = ranks[reverseIdx].data[txn]
 			foundMainTaxon = true
 		}
 	}
 	res.MainTaxon = mainTaxon
 	res.MainTaxonPercentage = txnPCent
+	if foundMainTaxon {
+		res.MainTaxonIsComplete = txnNamesNum == namesNum
+		res.NamesOutsideMainTaxon = namesNum - txnNamesNum
+	}
+	if o.botanicalRankNames {
+		useBotanicalRankNames(res)
+	}
+}
+
+// botanicalKingdoms are kingdoms that use botanical names of ranks.
+var botanicalKingdoms = map[string]struct{}{
+	"Plantae": {},
+	"Fungi":   {},
+}
+
+// useBotanicalRankNames changes the rank string of phylum taxons to
+// "division" if the prevalent kingdom uses botanical nomenclature.
+func useBotanicalRankNames(res *Stats) {
+	if _, ok := botanicalKingdoms[res.Kingdom.Name]; !ok {
+		return
+	}
+	if res.Phylum.Rank == Phylum {
+		res.Phylum.RankStr = "division"
+	}
+	if res.MainTaxon.Rank == Phylum {
+		res.MainTaxon.RankStr = "division"
+	}
+}
+
+// Prevalent returns the most prevalent taxon and its percentage for the
+// given rank. It works for kingdom, phylum, class, order, family, genus and
+// species, for all other ranks it returns zero values.
+func (s Stats) Prevalent(rank Rank) (Taxon, float32) {
+	switch rank {
+	case Kingdom:
+		return s.Kingdom, s.KingdomPercentage
+	case Phylum:
+		return s.Phylum, s.PhylumPercentage
+	case Class:
+		return s.Class, s.ClassPercentage
+	case Order:
+		return s.Order, s.OrderPercentage
+	case Family:
+		return s.Family, s.FamilyPercentage
+	case Genus:
+		return s.Genus, s.GenusPercentage
+	case Species:
+		return s.Species, s.SpeciesPercentage
+	default:
+		return Taxon{}, 0
+	}
+}
+
+// PrevalentCount returns the number of names that belong to the prevalent
+// taxon of the given rank (see Prevalent). It returns 0 if there is no
+// prevalent taxon. If detailed counts are not available, for example for
+// Stats decoded from JSON, the number is estimated from the percentage.
+func (s Stats) PrevalentCount(rank Rank) int {
+	txn, pcent := s.Prevalent(rank)
+	if txn.IsZero() {
+		return 0
+	}
+	for k, v := range s.rankCounts[rank] {
+		if k.ID == txn.ID && k.Name == txn.Name {
+			return v
+		}
+	}
+	return int(math.Round(float64(pcent) * float64(s.NamesNum)))
+}
+
+// Percent100 returns the percentage of names in the prevalent taxon of
+// the given rank as a value between 0 and 100. Percentage fields of Stats
+// contain values between 0 and 1.
+func (s Stats) Percent100(rank Rank) float32 {
+	_, pcent := s.Prevalent(rank)
+	return pcent * 100
+}
+
+// MainTaxonPercent100 returns the percentage of names in the MainTaxon as
+// a value between 0 and 100.
+func (s Stats) MainTaxonPercent100() float32 {
+	return s.MainTaxonPercentage * 100
+}
+
+// ResolutionRate returns the fraction of names that have a taxon of
+// the given rank. It is calculated by dividing the number of names
+// with a taxon at the rank by NamesNum, so it is never more than 1.
+// Ranks with less names than OptMinRankSamples are counted too.
+func (s Stats) ResolutionRate(rank Rank) float32 {
+	if s.NamesNum == 0 {
+		return 0
+	}
+	return float32(s.resolved[rank]) / float32(s.NamesNum)
+}
+
+// Coherence returns a value between 0 and 1 that describes how
+// taxonomically tight the group of names is. It is the arithmetic mean of
+// the percentages of names in the most prevalent taxon of each of the
+// kingdom, phylum, class, order, family and genus ranks. Only ranks that
+// had data are used. For ties the percentage of one of the tied taxa is
+// used. If none of these ranks had data, the result is 0.
+func (s Stats) Coherence() float32 {
+	var sum float32
+	var count int
+	for _, r := range majorRanks {
+		if v, ok := s.topPercentages[r]; ok {
+			sum += v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float32(count)
+}
+
+// MaxThresholdForRank returns the highest threshold at which MainTaxon
+// still has the given rank or a lower one. MainTaxon is found for all
+// thresholds lower than the returned value (and for the value itself with
+// OptInclusiveThreshold). The value is the highest percentage of the top
+// taxons of ranks allowed for MainTaxon (see OptMainTaxonRanks) that are
+// not higher than the rank. Thresholds set by OptRankThresholds and
+// the MajorityRelative mode are not taken into account. Thresholds lower
+// than 0.5 require OptAllowMinorityThreshold. It returns 0 if there is
+// no such rank with data.
+func (s Stats) MaxThresholdForRank(rank Rank) float32 {
+	var res float32
+	for r, pcent := range s.topPercentages {
+		if r.AtMost(Unknown) || !r.AtMost(rank) || pcent <= res {
+			continue
+		}
+		if !s.opts.isMainTaxonRank(r) {
+			continue
+		}
+		res = pcent
+	}
 	return res
 }
 
+// DeepestCompleteRank returns the lowest rank at which one taxon contains
+// all names. Unlike MainTaxon it does not depend on the threshold. It
+// returns Empty if there is no such rank.
+func (s Stats) DeepestCompleteRank() Rank {
+	if s.NamesNum == 0 {
+		return Empty
+	}
+	for r := SubSpecies; r <= Empire; r++ {
+		for _, v := range s.rankCounts[r] {
+			if v == s.NamesNum {
+				return r
+			}
+		}
+	}
+	return Empty
+}
+
+// MonophyleticRanks returns all ranks at which one taxon contains all
+// names, ordered from the highest to the lowest rank.
+func (s Stats) MonophyleticRanks() []Rank {
+	var res []Rank
+	for _, r := range Ranks() {
+		if pcent, ok := s.topPercentages[r]; ok && pcent == 1 {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// InvariantTaxa returns taxons that contain all names, by their ranks.
+// Only ranks where one taxon has all names are included, the same ranks
+// as returned by MonophyleticRanks. Taxons of Empty and Unknown ranks are
+// ignored.
+func (s Stats) InvariantTaxa() map[Rank]Taxon {
+	res := make(map[Rank]Taxon)
+	if s.NamesNum == 0 {
+		return res
+	}
+	for r, counts := range s.rankCounts {
+		if r.AtMost(Unknown) {
+			continue
+		}
+		for k, v := range counts {
+			if v == s.NamesNum {
+				res[r] = k
+			}
+		}
+	}
+	return res
+}
+
+// MonotypicChain returns the unbranched path from the top of the names'
+// hierarchy: taxons of consecutive ranks, starting from the highest rank
+// with data, that contain all names. It stops at the first rank where
+// names split between several taxons, or where some names do not have
+// the rank. Ranks without data are skipped. It returns nil if there are
+// no names.
+func (s Stats) MonotypicChain() []Taxon {
+	if s.NamesNum == 0 {
+		return nil
+	}
+	var res []Taxon
+	for _, r := range Ranks() {
+		counts := s.rankCounts[r]
+		if r.AtMost(Unknown) || len(counts) == 0 {
+			continue
+		}
+		if len(counts) > 1 {
+			break
+		}
+		var txn Taxon
+		for k, v := range counts {
+			if v == s.NamesNum {
+				txn = k
+			}
+		}
+		if txn.IsZero() {
+			break
+		}
+		res = append(res, txn)
+	}
+	return res
+}
+
+// isMainTaxon checks if the most prevalent taxon of a rank can be
+// the MainTaxon.
+func isMainTaxon(
+	rd rankData,
+	txn Taxon,
+	pcent, threshold float32,
+	o options,
+) bool {
+	if o.majorityMode == MajorityRelative {
+		return !txn.IsZero() && isPlurality(rd, txn)
+	}
+	return meetsThreshold(pcent, o.threshold(rd.rank, threshold), o)
+}
+
+// isPlurality checks if no other taxon of a rank has the same count as
+// the given taxon.
+func isPlurality(rd rankData, txn Taxon) bool {
+	count := rd.count(txn)
+	var num int
+	for k := range rd.data {
+		if rd.count(k) == count {
+			num++
+		}
+	}
+	return num == 1
+}
+
+// meetsThreshold checks if a percentage is sufficient for a MainTaxon.
+func meetsThreshold(pcent, threshold float32, o options) bool {
+	if o.inclusiveThreshold {
+		return pcent >= threshold
+	}
+	return pcent > threshold
+}
+
+// coDominant returns all taxons of a rank that have the highest number of
+// names, sorted by ID and name.
+func coDominant(rd rankData) []Taxon {
+	var max float64
+	for k := range rd.data {
+		if v := rd.count(k); v > max {
+			max = v
+		}
+	}
+	var res []Taxon
+	for k := range rd.data {
+		if rd.count(k) == max {
+			res = append(res, k)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return taxonLess(res[i], res[j])
+	})
+	return res
+}
+
+// taxonLess is used to break ties between taxons deterministically. It
+// compares taxons by ID, and then by name.
+func taxonLess(a, b Taxon) bool {
+	if a.ID != b.ID {
+		return a.ID < b.ID
+	}
+	return a.Name < b.Name
+}
+
 func isMaxTaxon(cd []TaxonDist, percentage float32) bool {
 	var count int
 	for i := range cd {
@@ -217,71 +957,340 @@ func isMaxTaxon(cd []TaxonDist, percentage float32) bool {
 	return count == 1
 }
 
-func getTaxDist(namesNum int, tx rankData) []TaxonDist {
-	res := make([]TaxonDist, len(tx.data))
-	var i int
+// appendTaxDist appends the distribution of names across taxons of a rank
+// to buf.
+func appendTaxDist(buf []TaxonDist, namesNum int, tx rankData) []TaxonDist {
+	if namesNum == 0 {
+		return buf
+	}
 	for k, v := range tx.data {
 		cd := TaxonDist{
 			NamesNum:   v,
+			ID:         k.ID,
 			Name:       k.Name,
-			Percentage: float32(v) / float32(namesNum),
+			Percentage: tx.share(k, namesNum),
+		}
+		if tx.total > 0 {
+			cd.CoverageShare = float32(v) / float32(tx.total)
 		}
-		res[i] = cd
-		i++
+		buf = append(buf, cd)
 	}
-	return res
+	return buf
+}
+
+// sortTaxDist sorts distribution by percentage in descending order,
+// then by ID and by name.
+func sortTaxDist(td []TaxonDist) {
+	sort.Slice(td, func(i, j int) bool {
+		if td[i].Percentage != td[j].Percentage {
+			return td[i].Percentage > td[j].Percentage
+		}
+		if td[i].ID != td[j].ID {
+			return td[i].ID < td[j].ID
+		}
+		return td[i].Name < td[j].Name
+	})
+}
+
+// normalizeDist sorts a distribution (see sortTaxDist) and adjusts
+// the percentage of the largest taxon, so the sum of all percentages
+// is exactly 1 in float32. It returns the new percentage of the largest
+// taxon.
+func normalizeDist(td []TaxonDist) float32 {
+	if len(td) == 0 {
+		return 0
+	}
+	sortTaxDist(td)
+	for i := 0; i < 100; i++ {
+		var sum float32
+		for _, v := range td {
+			sum += v.Percentage
+		}
+		switch {
+		case sum == 1:
+			return td[0].Percentage
+		case i == 0:
+			td[0].Percentage += 1 - sum
+		case sum < 1:
+			td[0].Percentage = math.Nextafter32(td[0].Percentage, 2)
+		default:
+			td[0].Percentage = math.Nextafter32(td[0].Percentage, 0)
+		}
+	}
+	return td[0].Percentage
 }
 
 func maxTaxon(namesNum int, rd rankData) (Taxon, float32) {
-	var max int
+	if namesNum == 0 {
+		return Taxon{}, 0
+	}
+	var max float64
 	var res, cld Taxon
-	for k, v := range rd.data {
-		if v > max {
+	for k := range rd.data {
+		v := rd.count(k)
+		if v > max || (v == max && taxonLess(k, cld)) {
 			max = v
 			cld = k
 		}
 	}
-	if cld.Name != "" {
-		res = cld
+	if cld.IsZero() {
+		return res, 0
 	}
-	return res, float32(max) / float32(namesNum)
+	res = cld
+	return res, rd.share(res, namesNum)
 }
 
 // extractTaxons collects taxons for each name. It only collects names that
 // are genus or less. It does not make sense to take in account higher
 // classification ranks because their meaning can be different than in
 // the Catalogue of Life.
-func extractTaxons(h []Hierarchy) [][]Taxon {
-	var taxons []Taxon
+func extractTaxons(h []Hierarchy, o options) [][]Taxon {
 	res := make([][]Taxon, 0, len(h))
 	for i := range h {
-		var genusOrLess bool
-		taxons = h[i].Taxons()
-		for ii := range taxons {
-			if taxons[ii].Rank == Empty {
-				taxons[ii].Rank = NewRank(taxons[ii].RankStr)
+		if taxons, ok, _ := qualifiedTaxons(i, h[i], o); ok {
+			res = append(res, taxons)
+		}
+	}
+	if reps := o.representatives(res); reps != nil {
+		for i := range res {
+			res[i] = replaceTaxons(res[i], reps)
+		}
+	}
+	return res
+}
+
+// qualifiedTaxons returns taxons of a hierarchy and true, if the
+// hierarchy contains a taxon of genus rank or lower (or of other rank set
+// by OptMinPoolRank, or of a rank accepted by OptQualifyRanks).
+//
+// Taxons received from the hierarchy are copied, so the data provided by
+// a caller stays unchanged. If ranked taxons are out of order, they are
+// sorted from the highest to the lowest rank.
+//
+// With OptStrictTree a qualified hierarchy that contains more than one
+// taxon of the same rank is not accepted, and the error of the check is
+// returned as well.
+func qualifiedTaxons(
+	idx int,
+	h Hierarchy,
+	o options,
+) ([]Taxon, bool, error) {
+	var qualified bool
+	hTaxons := h.Taxons()
+	taxons := make([]Taxon, 0, len(hTaxons))
+	for i := range hTaxons {
+		txn := hTaxons[i].WithResolvedRank()
+		if o.skipMinorRanks && txn.Rank.IsMinor() && txn.Rank != o.requireRank {
+			if !qualified && o.qualifies(txn.Rank) {
+				qualified = true
 			}
-			if !genusOrLess &&
-				taxons[ii].Rank != Unknown &&
-				taxons[ii].Rank <= Genus {
-				genusOrLess = true
+			continue
+		}
+		txn = o.normalize(txn)
+		if o.isPlaceholder(txn.Name) {
+			continue
+		}
+		if o.rankHeuristics && txn.RankStr == "" && txn.Rank.AtMost(Unknown) {
+			txn.Rank = guessRank(txn.Name, i == len(hTaxons)-1)
+		}
+		if o.logger != nil && txn.Rank == Unknown {
+			o.logger.Debug(
+				"unknown rank",
+				"index", idx,
+				"name", txn.Name,
+				"rank", txn.RankStr,
+			)
+		}
+		if !qualified && o.qualifies(txn.Rank) {
+			qualified = true
+		}
+		taxons = append(taxons, txn)
+	}
+	if qualified && o.strictTree {
+		if err := checkTree(taxons); err != nil {
+			if o.logger != nil {
+				o.logger.Debug(
+					"dropped inconsistent hierarchy",
+					"index", idx,
+					"error", err.Error(),
+				)
 			}
+			return taxons, false, err
 		}
-		if genusOrLess {
-			res = append(res, taxons)
+	}
+	if qualified && o.lacksRequiredRank(taxons) {
+		if o.logger != nil {
+			o.logger.Debug(
+				"dropped hierarchy without the required rank",
+				"index", idx,
+				"requiredRank", o.requireRank.String(),
+			)
+		}
+		return taxons, false, nil
+	}
+	if qualified && !isSorted(taxons) {
+		SortTaxons(taxons)
+	}
+	if !qualified && o.logger != nil {
+		o.logger.Debug(
+			"dropped hierarchy without taxa of the minimal pool rank or lower",
+			"index", idx,
+			"minPoolRank", o.minPoolRank.String(),
+			"taxonsNum", len(taxons),
+		)
+	}
+	return taxons, qualified, nil
+}
+
+// speciesKey returns a key used to find hierarchies of the same species.
+// The species is determined by the ID of a species-level taxon (by its name
+// if ID is empty). Hierarchies without a species use their genus.
+func speciesKey(taxons []Taxon) string {
+	var res string
+	for _, v := range taxons {
+		if v.Rank == Species || (v.Rank == Genus && res == "") {
+			res = v.Rank.String() + "|" + v.ID
+			if v.ID == "" {
+				res += "|" + v.Name
+			}
 		}
 	}
 	return res
 }
 
-// removeEmptyRanks removes empty ranks
-func removeEmptyRanks(ranks []rankData) []rankData {
+// rankData returns the counts of a rank that were saved during
+// the calculation.
+func (s Stats) rankData(rank Rank) rankData {
+	res := rankData{
+		rank:  rank,
+		data:  s.rankCounts[rank],
+		total: s.rankTotals[rank],
+	}
+	if sourced, ok := s.rankSourced[rank]; ok {
+		res.sourced = sourced
+		res.sourcedNames = s.sourcedNames
+	}
+	return res
+}
+
+// removeEmptyRanks removes empty ranks and ranks that have less than
+// minSamples names.
+func removeEmptyRanks(ranks []rankData, minSamples int) []rankData {
 	var res []rankData
 	for i := range ranks {
-		if ranks[i].total == 0 {
+		if ranks[i].total == 0 || ranks[i].total < minSamples {
 			continue
 		}
 		res = append(res, ranks[i])
 	}
 	return res
 }
+
+// RankSummary contains the main metrics of a rank.
+type RankSummary struct {
+	// Rank is the rank of the summary.
+	Rank Rank `json:"rank"`
+
+	// TopTaxon is the most prevalent taxon of the rank. It is empty if
+	// several taxons share the highest number of names (except for kingdoms,
+	// see Stats.KingdomTie).
+	TopTaxon Taxon `json:"topTaxon"`
+
+	// Percentage is the percentage of names in the largest taxon of
+	// the rank.
+	Percentage float32 `json:"percentage"`
+
+	// Richness is the number of taxons of the rank.
+	Richness int `json:"richness"`
+
+	// Shannon is the Shannon diversity index of the rank.
+	Shannon float64 `json:"shannon"`
+}
+
+// RankSummaries returns summaries of all ranks that had data, ordered from
+// the highest to the lowest rank. Taxons without a known rank are not
+// included. For ranks supported by Prevalent the TopTaxon is the same as
+// the prevalent taxon.
+func (s Stats) RankSummaries() []RankSummary {
+	var res []RankSummary
+	for _, r := range Ranks() {
+		counts := s.rankCounts[r]
+		if r.AtMost(Unknown) || len(counts) == 0 {
+			continue
+		}
+		rd := s.rankData(r)
+		txn, pcent := maxTaxon(s.NamesNum, rd)
+		switch {
+		case r == Species || isMajorRank(r):
+			top, topPcent := s.Prevalent(r)
+			txn = top
+			if !top.IsZero() {
+				pcent = topPcent
+			}
+		case !isPlurality(rd, txn):
+			txn = Taxon{}
+		}
+		res = append(res, RankSummary{
+			Rank:       r,
+			TopTaxon:   txn,
+			Percentage: pcent,
+			Richness:   len(counts),
+			Shannon:    s.ShannonIndex(r),
+		})
+	}
+	return res
+}
+
+// RankEntry is a flattened representation of a prevalent taxon of a rank.
+type RankEntry struct {
+	// Rank is the rank of the entry.
+	Rank Rank `json:"rank"`
+
+	// Taxon is the prevalent taxon of the rank.
+	Taxon Taxon `json:"taxon"`
+
+	// Percentage is the percentage of names in the taxon.
+	Percentage float32 `json:"percentage"`
+
+	// IsMain is true if the taxon is the MainTaxon.
+	IsMain bool `json:"isMain"`
+}
+
+// Entries returns prevalent taxa of kingdom, phylum, class, order, family
+// and genus as a flat list ordered from higher to lower ranks. Only ranks
+// with a prevalent taxon are included. The entry of the MainTaxon is
+// flagged, if MainTaxon has a rank not covered by the prevalent taxa, it is
+// added to the list according to its rank.
+func (s Stats) Entries() []RankEntry {
+	var res []RankEntry
+	var mainAdded bool
+	hasMain := !s.MainTaxon.IsZero()
+	addMain := func() {
+		res = append(res, RankEntry{
+			Rank:       s.MainTaxon.Rank,
+			Taxon:      s.MainTaxon,
+			Percentage: s.MainTaxonPercentage,
+			IsMain:     true,
+		})
+		mainAdded = true
+	}
+
+	for _, r := range majorRanks {
+		if hasMain && !mainAdded && s.MainTaxon.Rank > r {
+			addMain()
+		}
+		txn, pcent := s.Prevalent(r)
+		if txn.IsZero() {
+			continue
+		}
+		if hasMain && !mainAdded && txn == s.MainTaxon {
+			addMain()
+			continue
+		}
+		res = append(res, RankEntry{Rank: r, Taxon: txn, Percentage: pcent})
+	}
+	if hasMain && !mainAdded {
+		addMain()
+	}
+	return res
+}
@@ -2,11 +2,15 @@ package stats_test
 
 import (
 	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/gnames/gnstats/ent/stats"
@@ -49,6 +53,21 @@ func TestTaxons(t *testing.T) {
 	assert.InDelta(float32(0.55), res.MainTaxonPercentage, 0.01)
 }
 
+func TestMainTaxonComplete(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	res := stats.New(hs, 0.7)
+	assert.Equal("Mollusca", res.MainTaxon.Name)
+	assert.True(res.MainTaxonIsComplete)
+	assert.Equal(0, res.NamesOutsideMainTaxon)
+
+	res = stats.New(hs, 0.5)
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+	assert.False(res.MainTaxonIsComplete)
+	assert.Equal(31, res.NamesOutsideMainTaxon)
+	assert.Equal(69, res.NamesNum)
+}
+
 // TestFishes tests situation where some sequence of ranks varies from
 // name to name, and some of the names are higher than genus.
 func TestFishes(t *testing.T) {
@@ -69,13 +88,760 @@ func TestReptiles(t *testing.T) {
 	assert.Equal(t, 628, len(hs))
 	res := stats.New(hs, 0.5)
 	assert.Equal(t, 619, res.NamesNum)
+	assert.Equal(t, 628, res.InputCount)
+	assert.Equal(t, 9, res.DroppedNames)
+	assert.Equal(t, res.InputCount, res.NamesNum+res.DroppedNames)
 	assert.Equal(t, "Animalia", res.Kingdom.Name)
 	assert.InDelta(t, float32(0.97), res.KingdomPercentage, 0.01)
 	assert.Equal(t, "Squamata", res.MainTaxon.Name)
 	assert.InDelta(t, float32(0.92), res.MainTaxonPercentage, 0.01)
 }
 
+func TestEntries(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	res := stats.New(hs, 0.5)
+	es := res.Entries()
+	assert.Equal(6, len(es))
+	names := []string{
+		"Animalia", "Chordata", "Reptilia", "Squamata", "Dactyloidae", "Anolis",
+	}
+	ranks := []stats.Rank{
+		stats.Kingdom, stats.Phylum, stats.Class,
+		stats.Order, stats.Family, stats.Genus,
+	}
+	for i, v := range es {
+		assert.Equal(names[i], v.Taxon.Name)
+		assert.Equal(ranks[i], v.Rank)
+		assert.Equal(v.Taxon.Name == "Squamata", v.IsMain)
+	}
+	assert.Equal(res.MainTaxonPercentage, es[3].Percentage)
+
+	hs = fiftyFifty()
+	res = stats.New(hs, 0.5, stats.OptInclusiveThreshold(true))
+	es = res.Entries()
+	assert.Equal(2, len(es))
+	// the tied kingdom is resolved deterministically
+	assert.Equal("Animalia", es[0].Taxon.Name)
+	assert.False(es[0].IsMain)
+	assert.Equal("Magnoliopsida", es[1].Taxon.Name)
+	assert.True(es[1].IsMain)
+}
+
+func TestRankSummaries(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	sums := res.RankSummaries()
+	assert.Greater(len(sums), 6)
+	for i := 1; i < len(sums); i++ {
+		assert.Greater(sums[i-1].Rank, sums[i].Rank)
+	}
+	byRank := make(map[stats.Rank]stats.RankSummary)
+	for _, v := range sums {
+		byRank[v.Rank] = v
+	}
+
+	kingdom := byRank[stats.Kingdom]
+	assert.Equal("Animalia", kingdom.TopTaxon.Name)
+	assert.Equal(float32(1), kingdom.Percentage)
+	assert.Equal(1, kingdom.Richness)
+	assert.Equal(0.0, kingdom.Shannon)
+
+	class := byRank[stats.Class]
+	assert.Equal(res.Class, class.TopTaxon)
+	assert.Equal(res.ClassPercentage, class.Percentage)
+	assert.Equal(len(res.Distribution(stats.Class)), class.Richness)
+	assert.Equal(res.ShannonIndex(stats.Class), class.Shannon)
+	assert.Greater(class.Shannon, 0.0)
+
+	genus := byRank[stats.Genus]
+	assert.True(genus.TopTaxon.IsZero())
+	assert.Equal(res.Distribution(stats.Genus)[0].Percentage, genus.Percentage)
+
+	_, ok := byRank[stats.Unknown]
+	assert.False(ok)
+	assert.Nil(stats.Stats{}.RankSummaries())
+}
+
+func TestReversedHierarchies(t *testing.T) {
+	hs := testData(t)
+	rev := make([]stats.Hierarchy, len(hs))
+	for i := range hs {
+		taxons := hs[i].Taxons()
+		revTaxons := make([]stats.Taxon, len(taxons))
+		for ii := range taxons {
+			revTaxons[len(taxons)-1-ii] = taxons[ii]
+		}
+		rev[i] = classif{clades: revTaxons}
+	}
+	res := stats.New(hs, 0.5)
+	resRev := stats.New(rev, 0.5)
+	assert.True(t, res.Equal(resRev))
+	assert.Equal(t, "Gastropoda", resRev.MainTaxon.Name)
+}
+
+func TestResolutionRate(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "unranked|kingdom|phylum|class|order|family|species"
+	hs := []stats.Hierarchy{
+		newHry(
+			"Biota|Animalia|Mollusca|Gastropoda|Neogastropoda|Muricidae|Murex|Murex pecten",
+			"unranked|kingdom|phylum|class|order|family|genus|species",
+			"5T6MX|N|M2L|7NF3Y|7NF59|7NVY3|7NZ3Y|7PF7N",
+		),
+		newHry(
+			"Biota|Animalia|Mollusca|Gastropoda|Neogastropoda|Muricidae|Murex troscheli",
+			ranks,
+			"5T6MX|N|M2L|7NF3Y|7NF59|7NVY3|7PF7P",
+		),
+		newHry(
+			"Biota|Animalia|Mollusca|Gastropoda|Neogastropoda|Conidae|Conus textile",
+			ranks,
+			"5T6MX|N|M2L|7NF3Y|7NF59|7NVXK|7PF7Q",
+		),
+		newHry(
+			"Biota|Animalia|Mollusca|Bivalvia|Ostreida|Ostreidae|Ostrea edulis",
+			ranks,
+			"5T6MX|N|M2L|7NF3Z|7NF5C|7NVXL|7PF7R",
+		),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal(4, res.NamesNum)
+	assert.Equal(float32(0.25), res.GenusResolutionRate)
+	assert.Equal(float32(0.25), res.ResolutionRate(stats.Genus))
+	assert.Equal(float32(1), res.ResolutionRate(stats.Family))
+	assert.Equal(float32(1), res.ResolutionRate(stats.Species))
+	assert.Equal(float32(0), res.ResolutionRate(stats.SubFamily))
+	assert.Equal(float32(0), stats.Stats{}.ResolutionRate(stats.Genus))
+
+	// a name with two kingdoms is counted once
+	hs = append(hs, newHry("Animalia|Plantae|Bubo|Bubo bubo",
+		"kingdom|kingdom|genus|species", "N|P|3DQQ|1"))
+	res = stats.New(hs, 0.5)
+	assert.Equal(float32(1), res.ResolutionRate(stats.Kingdom))
+	assert.Equal(float32(0.4), res.GenusResolutionRate)
+}
+
+func TestReset(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	res := stats.New(hs, 0.5)
+	assert.Greater(len(res.Kingdoms), 1)
+	capacity := cap(res.Kingdoms)
+	res.Reset()
+	assert.True(res.Equal(stats.Stats{}))
+	assert.Empty(res.Entropies())
+	assert.Equal(0, len(res.Kingdoms))
+	assert.Equal(capacity, cap(res.Kingdoms))
+	assert.Equal(float32(0), res.Coherence())
+}
+
+func TestCalcInto(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	var res stats.Stats
+	stats.CalcInto(&res, hs, 0.5)
+	assert.True(res.Equal(stats.New(hs, 0.5)))
+
+	mol := testData(t)
+	stats.CalcInto(&res, mol, 0.5)
+	assert.True(res.Equal(stats.New(mol, 0.5)))
+	assert.Equal("Gastropoda", res.MainTaxon.Name)
+
+	stats.CalcInto(&res, hs[:1], 0.5)
+	assert.True(res.Equal(stats.New(hs[:1], 0.5)))
+	assert.Equal(stats.ReasonSingleName, res.EmptyReason)
+
+	// reused Stats encode the same way as new ones after a tie
+	ff := fiftyFifty()
+	stats.CalcInto(&res, ff, 0.5)
+	assert.NotEmpty(res.CoDominant)
+	for _, group := range [][]stats.Hierarchy{mol, ff, hs} {
+		stats.CalcInto(&res, group, 0.5)
+		resJSON, err := json.Marshal(res)
+		assert.Nil(err)
+		expJSON, err := json.Marshal(stats.New(group, 0.5))
+		assert.Nil(err)
+		assert.JSONEq(string(expJSON), string(resJSON))
+	}
+	assert.Nil(res.CoDominant)
+}
+
+func TestClone(t *testing.T) {
+	assert := assert.New(t)
+	var res stats.Stats
+	stats.CalcInto(&res, testData(t), 0.5)
+	exp := stats.New(testData(t), 0.5)
+	saved := res.Clone()
+	entropies := res.Entropies()
+	assert.True(saved.Equal(exp))
+
+	stats.CalcInto(&res, fiftyFifty(), 0.5)
+	assert.True(saved.Equal(exp))
+	assert.Equal(exp.Kingdoms, saved.Kingdoms)
+	assert.Equal(exp.Distribution(stats.Family), saved.Distribution(stats.Family))
+	assert.Equal(len(exp.Entropies()), len(entropies))
+	for k, v := range exp.Entropies() {
+		assert.InDelta(v, entropies[k], 1e-9)
+	}
+	assert.Equal(exp.ChildShares("Muricidae"), saved.ChildShares("Muricidae"))
+	assert.True(res.Equal(stats.New(fiftyFifty(), 0.5)))
+}
+
+func BenchmarkCalcInto(b *testing.B) {
+	hs := taxons2(b, "reptiles.csv")
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = stats.New(hs, 0.5)
+		}
+	})
+	b.Run("Pool", func(b *testing.B) {
+		pool := sync.Pool{New: func() any { return new(stats.Stats) }}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			res := pool.Get().(*stats.Stats)
+			stats.CalcInto(res, hs, 0.5)
+			pool.Put(res)
+		}
+	})
+}
+
+func TestCoDominant(t *testing.T) {
+	assert := assert.New(t)
+	ranks := "kingdom|phylum|class|order|family|genus"
+	hs := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo", ranks,
+			"N|CH2|V2|466|GQX|3DQQ"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Strix", ranks,
+			"N|CH2|V2|466|GQX|6W7S"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Tytonidae|Tyto", ranks,
+			"N|CH2|V2|466|GQW|6Y1F"),
+		newHry("Animalia|Chordata|Aves|Strigiformes|Tytonidae|Phodilus", ranks,
+			"N|CH2|V2|466|GQW|6Y1G"),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal("", res.Family.Name)
+	assert.Equal(float32(0), res.FamilyPercentage)
+	fams := res.CoDominant[stats.Family]
+	assert.Equal(2, len(fams))
+	assert.Equal("Tytonidae", fams[0].Name)
+	assert.Equal("Strigidae", fams[1].Name)
+	assert.Equal(4, len(res.CoDominant[stats.Genus]))
+	_, ok := res.CoDominant[stats.Order]
+	assert.False(ok)
+	assert.Equal("Strigiformes", res.Order.Name)
+
+	res = stats.New(hs[:3], 0.5)
+	assert.Nil(res.CoDominant[stats.Family])
+	assert.Equal("Strigidae", res.Family.Name)
+}
+
+func TestMaxThresholdForRank(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	res := stats.New(hs, 0.5)
+	max := res.MaxThresholdForRank(stats.Family)
+	assert.Equal(res.FamilyPercentage, max)
+	assert.Greater(max, float32(0))
+	assert.Less(max, res.MainTaxonPercentage)
+
+	allow := stats.OptAllowMinorityThreshold(true)
+	below := stats.New(hs, max-0.001, allow)
+	assert.True(below.MainTaxon.Rank.AtMost(stats.Family))
+	at := stats.New(hs, max, allow)
+	assert.Greater(at.MainTaxon.Rank, stats.Family)
+	at = stats.New(hs, max, allow, stats.OptInclusiveThreshold(true))
+	assert.True(at.MainTaxon.Rank.AtMost(stats.Family))
+
+	assert.Equal(res.MainTaxonPercentage,
+		res.MaxThresholdForRank(res.MainTaxon.Rank))
+	assert.Equal(res.KingdomPercentage, res.MaxThresholdForRank(stats.Kingdom))
+	assert.Equal(float32(0), res.MaxThresholdForRank(stats.SubSpecies))
+}
+
+func TestInvariantTaxa(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	inv := res.InvariantTaxa()
+	assert.Equal(2, len(inv))
+	assert.Equal("Animalia", inv[stats.Kingdom].Name)
+	assert.Equal("Mollusca", inv[stats.Phylum].Name)
+	assert.Equal(res.Phylum, inv[stats.Phylum])
+	assert.Equal(len(res.MonophyleticRanks()), len(inv))
+
+	res = stats.New(fiftyFifty(), 0.5)
+	assert.Empty(res.InvariantTaxa())
+	assert.Empty(stats.Stats{}.InvariantTaxa())
+}
+
+func TestMonotypicChain(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	chain := res.MonotypicChain()
+	assert.Equal(2, len(chain))
+	assert.Equal("Animalia", chain[0].Name)
+	assert.Equal("Mollusca", chain[1].Name)
+	assert.Equal(res.Kingdom, chain[0])
+
+	ff := fiftyFifty()
+	res = stats.New([]stats.Hierarchy{ff[1], ff[3]}, 0.5)
+	var names []string
+	for _, v := range res.MonotypicChain() {
+		names = append(names, v.Name)
+	}
+	assert.Equal([]string{"Animalia", "Chordata"}, names)
+
+	assert.Empty(stats.New(fiftyFifty(), 0.5).MonotypicChain())
+	assert.Nil(stats.Stats{}.MonotypicChain())
+}
+
+func TestDeepestCompleteRank(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	assert.Equal(stats.Phylum, res.DeepestCompleteRank())
+	assert.Equal("Mollusca", res.Phylum.Name)
+
+	res = stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	assert.Equal(stats.Empty, res.DeepestCompleteRank())
+
+	res = stats.New(append(testData(t), fiftyFifty()[3]), 0.5)
+	assert.Equal(stats.Kingdom, res.DeepestCompleteRank())
+	ff := fiftyFifty()
+	res = stats.New([]stats.Hierarchy{ff[1], ff[3]}, 0.5)
+	assert.Equal(stats.Phylum, res.DeepestCompleteRank())
+	assert.Equal(stats.Empty, stats.Stats{}.DeepestCompleteRank())
+}
+
+func TestMonophyleticRanks(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	assert.Equal([]stats.Rank{stats.Kingdom, stats.Phylum}, res.MonophyleticRanks())
+	assert.Equal(stats.Phylum, res.DeepestCompleteRank())
+
+	res = stats.New(fiftyFifty(), 0.5)
+	assert.Empty(res.MonophyleticRanks())
+}
+
+func TestGenera(t *testing.T) {
+	assert := assert.New(t)
+	hs := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigidae|Bubo|Bubo bubo",
+			"kingdom|phylum|class|family|genus|species", "N|CH2|V2|GQX|3DQQ|1"),
+		newHry("Animalia|Chordata|Aves|Strigidae|Strix|Strix aluco",
+			"kingdom|phylum|class|family|genus|species", "N|CH2|V2|GQX|6W7S|2"),
+		newHry("Animalia|Chordata|Aves|Strigidae|Otus scops",
+			"kingdom|phylum|class|family|species", "N|CH2|V2|GQX|3"),
+		newHry("Animalia|Chordata|Aves|Corvidae|Corvus corax",
+			"kingdom|phylum|class|family|species", "N|CH2|V2|FDR|4"),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal("", res.Genus.Name)
+	assert.NotNil(res.Genera)
+	assert.Equal(2, len(res.Genera))
+	assert.Equal("Bubo", res.Genera[0].Name)
+	assert.Equal(float32(0.25), res.Genera[0].Percentage)
+	assert.Equal("Strix", res.Genera[1].Name)
+
+	res = stats.New(hs[2:], 0.5)
+	assert.Nil(res.Genera)
+}
+
+func TestDeterministic(t *testing.T) {
+	assert := assert.New(t)
+	inputs := [][]stats.Hierarchy{
+		taxons2(t, "reptiles.csv"),
+		testData(t),
+		fiftyFifty(),
+	}
+	for _, hs := range inputs {
+		exp, err := json.Marshal(stats.New(hs, 0.5))
+		assert.Nil(err)
+		for i := 0; i < 100; i++ {
+			res := stats.New(hs, 0.5)
+			bs, err := json.Marshal(res)
+			assert.Nil(err)
+			assert.Equal(string(exp), string(bs))
+			assert.Equal(res.Kingdoms, res.Distribution(stats.Kingdom))
+		}
+	}
+}
+
 func TestFiftyFifty(t *testing.T) {
+	hr := fiftyFifty()
+	res := stats.New(hr, 0)
+	assert.Equal(t, res.Kingdom.Name, "Animalia")
+	assert.Equal(t, res.KingdomPercentage, float32(0.5))
+	assert.True(t, res.KingdomTie)
+	assert.Equal(t, 2, len(res.CoDominant[stats.Kingdom]))
+	assert.Equal(t, 2, len(res.Kingdoms))
+	assert.Equal(t, res.MainTaxon.Name, "")
+	assert.Equal(t, res.MainTaxonPercentage, float32(0))
+}
+
+func TestKingdomsWithTie(t *testing.T) {
+	assert := assert.New(t)
+	hr := fiftyFifty()
+	opts := [][]stats.Option{
+		nil,
+		{stats.OptMajorityMode(stats.MajorityRelative)},
+		{stats.OptAllowMinorityThreshold(true)},
+	}
+	for _, threshold := range []float32{0, 0.5, 1} {
+		for _, o := range opts {
+			res := stats.New(hr, threshold, o...)
+			assert.True(res.KingdomTie)
+			assert.Equal(2, len(res.Kingdoms))
+			assert.Equal(res.Distribution(stats.Kingdom), res.Kingdoms)
+			assert.Equal(
+				[]string{"Animalia", "Plantae"},
+				[]string{res.Kingdoms[0].Name, res.Kingdoms[1].Name},
+			)
+		}
+	}
+
+	res := stats.CombineStats([]stats.WeightedStats{
+		{Stats: stats.New(hr[:2], 0.5), Weight: 1},
+		{Stats: stats.New(hr[2:], 0.5), Weight: 1},
+	})
+	assert.True(res.KingdomTie)
+	assert.Equal("Animalia", res.Kingdom.Name)
+	assert.Equal(float32(0.5), res.KingdomPercentage)
+	assert.Equal(2, len(res.Kingdoms))
+}
+
+func TestKingdomTie(t *testing.T) {
+	assert := assert.New(t)
+	hr := fiftyFifty()
+	for i := 0; i < 20; i++ {
+		// reverse order of hierarchies should not change the result
+		rev := []stats.Hierarchy{hr[3], hr[2], hr[1], hr[0]}
+		res := stats.New(rev, 0.5)
+		assert.True(res.KingdomTie)
+		assert.Equal("N", res.Kingdom.ID)
+		assert.Equal("Animalia", res.Kingdom.Name)
+		assert.Equal(float32(0.5), res.KingdomPercentage)
+	}
+
+	res := stats.New(testData(t), 0.5)
+	assert.False(res.KingdomTie)
+}
+
+func TestEmptyReason(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "taxons2.csv")
+	res := stats.New(hs, 0.5)
+	assert.Equal(stats.ReasonNone, res.EmptyReason)
+
+	res = stats.New(hs[:1], 0.5)
+	assert.Equal(stats.ReasonSingleName, res.EmptyReason)
+	assert.Equal(0, res.NamesNum)
+
+	// the second name is higher than genus
+	res = stats.New(hs[1:2], 0.5)
+	assert.Equal(stats.ReasonNoNames, res.EmptyReason)
+	assert.Equal(1, res.DroppedNames)
+
+	res = stats.New(nil, 0.5)
+	assert.Equal(stats.ReasonNoNames, res.EmptyReason)
+}
+
+func TestWarnings(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(taxons2(t, "taxons2.csv"), 0.5)
+	assert.Greater(res.DroppedNames, 0)
+	codes := make(map[stats.WarningCode]string)
+	for _, v := range res.Warnings {
+		codes[v.Code] = v.Message
+	}
+	assert.Contains(codes, stats.WarnDroppedNames)
+	assert.Contains(codes[stats.WarnDroppedNames],
+		fmt.Sprintf("%d of %d names", res.DroppedNames, res.InputCount))
+
+	res = stats.New(taxons2(t, "reptiles.csv"), 0.5)
+	codes = make(map[stats.WarningCode]string)
+	for _, v := range res.Warnings {
+		codes[v.Code] = v.Message
+	}
+	assert.Equal("names belong to 4 kingdoms", codes[stats.WarnMultipleKingdoms])
+
+	res = stats.New([]stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigidae|Bubo",
+			"kingdom|phylum|class|family|genus", "N|CH2|V2|GQX|3DQQ"),
+		newHry("Animalia|Chordata|Aves|Corvidae|Corvus",
+			"kingdom|phylum|class|foo|genus", "N|CH2|V2|FDR|4CR"),
+	}, 0.5)
+	codes = make(map[stats.WarningCode]string)
+	for _, v := range res.Warnings {
+		codes[v.Code] = v.Message
+	}
+	assert.Equal("1 taxa have unrecognized ranks", codes[stats.WarnUnknownRanks])
+	assert.Contains(codes, stats.WarnTie)
+
+	hs := testData(t)
+	res = stats.New([]stats.Hierarchy{hs[0], hs[0]}, 0.5)
+	assert.Nil(res.Warnings)
+}
+
+func TestNoNames(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "taxons2.csv")
+	// the second name is higher than genus
+	above := []stats.Hierarchy{hs[1], hs[1], hs[1]}
+	res := stats.New(above, 0.5)
+	exp := stats.Stats{
+		InputCount:   3,
+		DroppedNames: 3,
+		EmptyReason:  stats.ReasonNoNames,
+		Warnings: []stats.Warning{{
+			Code:    stats.WarnDroppedNames,
+			Message: "3 of 3 names did not qualify for the calculation",
+		}},
+	}
+	assert.True(res.Equal(exp))
+	assert.Equal(float32(0), res.Coherence())
+	assert.Equal(float32(0), res.ResolutionRate(stats.Kingdom))
+	assert.Empty(res.Entropies())
+	assert.Nil(res.Kingdoms)
+}
+
+func TestWithResolvedRank(t *testing.T) {
+	assert := assert.New(t)
+	tx := stats.Taxon{Name: "Bubo", RankStr: "genus"}
+	res := tx.WithResolvedRank()
+	assert.Equal(stats.Genus, res.Rank)
+	assert.Equal(stats.Empty, tx.Rank)
+
+	tx = stats.Taxon{Name: "Bubo", RankStr: "genus", Rank: stats.Family}
+	res = tx.WithResolvedRank()
+	assert.Equal(stats.Family, res.Rank)
+}
+
+func TestColURL(t *testing.T) {
+	assert := assert.New(t)
+	tx := stats.Taxon{ID: "3DQQ", Name: "Bubo"}
+	assert.Equal("https://www.catalogueoflife.org/data/taxon/3DQQ", tx.ColURL())
+	assert.Equal("", stats.Taxon{Name: "Bubo"}.ColURL())
+
+	base := stats.ColBaseURL
+	defer func() { stats.ColBaseURL = base }()
+	stats.ColBaseURL = "http://localhost:8080/taxon/"
+	assert.Equal("http://localhost:8080/taxon/3DQQ", tx.ColURL())
+}
+
+func TestTaxonIsZero(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(stats.Taxon{}.IsZero())
+	assert.True(stats.Taxon{Rank: stats.Unknown}.IsZero())
+	assert.False(stats.Taxon{Name: "Bubo", Rank: stats.Genus}.IsZero())
+	assert.False(stats.Taxon{Name: "Bubo"}.IsZero())
+	assert.False(stats.Taxon{ID: "3DQQ"}.IsZero())
+	assert.False(stats.Taxon{Rank: stats.Genus}.IsZero())
+}
+
+func TestNewNoMutation(t *testing.T) {
+	assert := assert.New(t)
+	hs := testData(t)
+	_ = stats.New(hs, 0.5)
+	for i := range hs {
+		for _, v := range hs[i].Taxons() {
+			assert.Equal(stats.Empty, v.Rank)
+		}
+	}
+}
+
+// TestSharedHierarchies checks that hierarchies can be reused across
+// several calculations without being changed.
+func TestSharedHierarchies(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "taxons2.csv")
+	hs[0].Taxons()[1].Rank = stats.Kingdom
+	res1 := stats.New(hs, 0.5)
+	res2 := stats.New(hs, 0.5)
+	assert.Equal(res1.MainTaxon, res2.MainTaxon)
+	assert.Equal(res1.NamesNum, res2.NamesNum)
+	for i := range hs {
+		for ii, v := range hs[i].Taxons() {
+			if i == 0 && ii == 1 {
+				assert.Equal(stats.Kingdom, v.Rank)
+				continue
+			}
+			assert.Equal(stats.Empty, v.Rank)
+		}
+	}
+}
+
+func TestCountUnit(t *testing.T) {
+	assert := assert.New(t)
+	paths := "Biota|Animalia|Chordata|Aves|Strigiformes|Strigidae|Striginae|Bubo|Bubo bubo"
+	ranks := "unranked|kingdom|phylum|class|order|family|subfamily|genus|species"
+	ids := "5T6MX|N|CH2|V2|466|GQX|KDK|3DQQ|NKSD"
+	hs := []stats.Hierarchy{
+		newHry(paths, ranks, ids),
+		newHry(paths, ranks, ids),
+		newHry(
+			"Biota|Animalia|Chordata|Mammalia|Carnivora|Felidae|Puma|Puma concolor",
+			"unranked|kingdom|phylum|class|order|family|genus|species",
+			"5T6MX|N|CH2|6224G|VS|623RM|75F9|4QHKG",
+		),
+	}
+	res := stats.New(hs, 0.5)
+	assert.Equal(3, res.NamesNum)
+	assert.Equal("Bubo", res.MainTaxon.Name)
+
+	res = stats.New(hs, 0.5, stats.OptCountUnit(stats.UnitSpecies))
+	assert.Equal(2, res.NamesNum)
+	assert.Equal(3, res.InputCount)
+	assert.Equal(1, res.DroppedNames)
+	assert.Equal("Chordata", res.MainTaxon.Name)
+}
+
+func TestKingdomDist(t *testing.T) {
+	assert := assert.New(t)
+	hs := taxons2(t, "reptiles.csv")
+	res := stats.New(hs, 0.5)
+	kingdoms := res.Kingdoms
+	sort.Slice(kingdoms, func(i, j int) bool {
+		if kingdoms[i].Percentage != kingdoms[j].Percentage {
+			return kingdoms[i].Percentage > kingdoms[j].Percentage
+		}
+		return kingdoms[i].Name < kingdoms[j].Name
+	})
+	kd := stats.KingdomDist(hs)
+	assert.Greater(len(kd), 1)
+	assert.Equal(kingdoms, kd)
+	assert.Equal("Animalia", kd[0].Name)
+
+	// options are applied the same way as in New
+	ranks := "kingdom|phylum|class|order|family|genus"
+	mixed := []stats.Hierarchy{
+		newHry("Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo", ranks,
+			"N|CH2|V2|466|GQX|3DQQ"),
+		newHry("Metazoa|Chordata|Aves|Passeriformes|Corvidae|Corvus", ranks,
+			"33208|CH2|V2|H4|C8R|6DBK"),
+		newHry("Plantae|Tracheophyta|Magnoliopsida|Lamiales|Plantaginaceae",
+			"kingdom|phylum|class|order|family", "P|TP|MG|LM|PL"),
+	}
+	optsList := [][]stats.Option{
+		nil,
+		{stats.OptCanonicalizeKingdoms(true)},
+		{stats.OptCanonicalizeKingdoms(true), stats.OptMinPoolRank(stats.Family)},
+		{stats.OptNormalizePercentages(true)},
+	}
+	for i, opts := range optsList {
+		exp := stats.New(mixed, 0.5, opts...).Kingdoms
+		assert.Equal(exp, stats.KingdomDist(mixed, opts...), i)
+	}
+	kd = stats.KingdomDist(mixed, stats.OptCanonicalizeKingdoms(true))
+	assert.Equal(1, len(kd))
+	assert.Equal("N", kd[0].ID)
+	assert.Equal(3, len(stats.KingdomDist(mixed, stats.OptMinPoolRank(stats.Family))))
+
+	assert.Nil(stats.KingdomDist(nil))
+}
+
+func BenchmarkKingdomDist(b *testing.B) {
+	hs := taxons2(b, "reptiles.csv")
+	b.Run("KingdomDist", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = stats.KingdomDist(hs)
+		}
+	})
+	b.Run("New", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = stats.New(hs, 0.5)
+		}
+	})
+}
+
+func TestPrevalent(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	fields := map[stats.Rank]struct {
+		taxon stats.Taxon
+		pcent float32
+	}{
+		stats.Kingdom: {res.Kingdom, res.KingdomPercentage},
+		stats.Phylum:  {res.Phylum, res.PhylumPercentage},
+		stats.Class:   {res.Class, res.ClassPercentage},
+		stats.Order:   {res.Order, res.OrderPercentage},
+		stats.Family:  {res.Family, res.FamilyPercentage},
+		stats.Genus:   {res.Genus, res.GenusPercentage},
+		stats.Species: {res.Species, res.SpeciesPercentage},
+	}
+	for _, r := range stats.Ranks() {
+		txn, pcent := res.Prevalent(r)
+		if f, ok := fields[r]; ok {
+			assert.Equal(f.taxon, txn, r.String())
+			assert.Equal(f.pcent, pcent, r.String())
+			continue
+		}
+		assert.Equal(stats.Taxon{}, txn, r.String())
+		assert.Equal(float32(0), pcent, r.String())
+	}
+	txn, _ := res.Prevalent(stats.Class)
+	assert.Equal("Gastropoda", txn.Name)
+}
+
+func TestPrevalentCount(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	assert.Equal("Muricidae", res.Family.Name)
+	assert.Equal(5, res.PrevalentCount(stats.Family))
+	assert.Equal(38, res.PrevalentCount(stats.Class))
+	assert.Equal(res.NamesNum, res.PrevalentCount(stats.Kingdom))
+	assert.Equal(0, res.PrevalentCount(stats.Genus))
+	assert.Equal(0, res.PrevalentCount(stats.SubClass))
+
+	bs, err := json.Marshal(res)
+	assert.Nil(err)
+	var decoded stats.Stats
+	assert.Nil(json.Unmarshal(bs, &decoded))
+	assert.Equal(5, decoded.PrevalentCount(stats.Family))
+}
+
+func TestSpecies(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	assert.Equal("Volvarina avena", res.Species.Name)
+	assert.Equal(stats.Species, res.Species.Rank)
+	assert.Equal(float32(2)/float32(69), res.SpeciesPercentage)
+	dist := res.Distribution(stats.Species)
+	assert.Equal("Volvarina avena", dist[0].Name)
+	assert.Equal(2, dist[0].NamesNum)
+	assert.Equal(1, dist[1].NamesNum)
+
+	res = stats.New(fiftyFifty(), 0.5)
+	assert.True(res.Species.IsZero())
+	assert.Equal(4, len(res.Distribution(stats.Species)))
+}
+
+func TestPercent100(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	assert.Equal(float32(0.5507246), res.ClassPercentage)
+	assert.InDelta(float32(55.07246), res.Percent100(stats.Class), 0.00001)
+	assert.Equal(float32(100), res.Percent100(stats.Kingdom))
+	assert.Equal(float32(0), res.Percent100(stats.SubClass))
+	assert.InDelta(float32(55.07246), res.MainTaxonPercent100(), 0.00001)
+}
+
+func TestCoherence(t *testing.T) {
+	assert := assert.New(t)
+	res := stats.New(testData(t), 0.5)
+	coherent := res.Coherence()
+	assert.Greater(coherent, float32(0.45))
+
+	res = stats.New(fiftyFifty(), 0.5)
+	scattered := res.Coherence()
+	assert.InDelta(float32(0.375), scattered, 0.0001)
+	assert.Greater(coherent, scattered)
+
+	assert.Equal(float32(0), stats.Stats{}.Coherence())
+}
+
+// fiftyFifty returns two plant and two animal hierarchies.
+func fiftyFifty() []stats.Hierarchy {
 	tests := []struct {
 		msg, paths, ranks, ids string
 	}{
@@ -108,14 +874,10 @@ func TestFiftyFifty(t *testing.T) {
 	for i, v := range tests {
 		hr[i] = newHry(v.paths, v.ranks, v.ids)
 	}
-	res := stats.New(hr, 0)
-	assert.Equal(t, res.Kingdom.Name, "")
-	assert.Equal(t, res.KingdomPercentage, float32(0))
-	assert.Equal(t, res.MainTaxon.Name, "")
-	assert.Equal(t, res.MainTaxonPercentage, float32(0))
+	return hr
 }
 
-func testData(t *testing.T) []stats.Hierarchy {
+func testData(t testing.TB) []stats.Hierarchy {
 	var res []stats.Hierarchy
 	var ids, names string
 	path := filepath.Join("..", "..", "testdata", "taxons.txt")
@@ -140,7 +902,7 @@ func testData(t *testing.T) []stats.Hierarchy {
 	return res
 }
 
-func taxons2(t *testing.T, fileName string) []stats.Hierarchy {
+func taxons2(t testing.TB, fileName string) []stats.Hierarchy {
 	var res []stats.Hierarchy
 	path := filepath.Join("..", "..", "testdata", fileName)
 
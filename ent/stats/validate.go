@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrEmptyName is returned by Taxon.Validate for taxons without a name.
+	ErrEmptyName = errors.New("taxon name is empty")
+
+	// ErrInvalidID is returned by Taxon.Validate when IDValidator rejects
+	// the ID of a taxon.
+	ErrInvalidID = errors.New("taxon ID is malformed")
+)
+
+// IDValidator checks IDs of taxons in Taxon.Validate. It is nil by
+// default, so IDs are not checked. ValidColID can be used for
+// the Catalogue of Life IDs.
+var IDValidator func(id string) bool
+
+// colIDRe matches IDs of the Catalogue of Life, for example "7NF3Y".
+var colIDRe = regexp.MustCompile(`^[0-9A-Z]{1,10}$`)
+
+// ValidColID checks if an ID looks like an ID of the Catalogue of Life:
+// up to 10 upper-case letters and digits.
+func ValidColID(id string) bool {
+	return colIDRe.MatchString(id)
+}
+
+// Validate checks if the taxon has a name and, if IDValidator is set,
+// if its ID is well-formed. Empty IDs are not checked. It helps to find
+// input errors, for example names and IDs in swapped columns. Validate is
+// not called during calculation of Stats.
+func (t Taxon) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("%w: ID '%s'", ErrEmptyName, t.ID)
+	}
+	if t.ID != "" && IDValidator != nil && !IDValidator(t.ID) {
+		return fmt.Errorf("%w: '%s' (name '%s')", ErrInvalidID, t.ID, t.Name)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package stats_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxonValidate(t *testing.T) {
+	assert := assert.New(t)
+	good := stats.Taxon{ID: "7NF3Y", Name: "Gastropoda", RankStr: "class"}
+	swapped := stats.Taxon{ID: "Gastropoda", Name: "7NF3Y", RankStr: "class"}
+
+	assert.Nil(good.Validate())
+	assert.Nil(swapped.Validate())
+	err := stats.Taxon{ID: "7NF3Y", RankStr: "class"}.Validate()
+	assert.True(errors.Is(err, stats.ErrEmptyName))
+
+	stats.IDValidator = stats.ValidColID
+	defer func() { stats.IDValidator = nil }()
+	assert.Nil(good.Validate())
+	assert.Nil(stats.Taxon{Name: "Gastropoda"}.Validate())
+	err = swapped.Validate()
+	assert.True(errors.Is(err, stats.ErrInvalidID))
+	assert.Contains(err.Error(), "Gastropoda")
+
+	for _, h := range testData(t) {
+		for _, v := range h.Taxons() {
+			if v.Name != "" {
+				assert.Nil(v.Validate(), v.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// verifRecord is a part of gnverifier output that contains
+// a classification of a name.
+type verifRecord struct {
+	classification
+	BestResult *classification `json:"bestResult"`
+}
+
+// classification contains pipe-delimited classification fields.
+type classification struct {
+	ClassificationPath  string `json:"classificationPath"`
+	ClassificationRanks string `json:"classificationRanks"`
+	ClassificationIDs   string `json:"classificationIds"`
+}
+
+// FromVerification reads output of gnverifier in JSON format and creates
+// a hierarchy for every name. The input can be a JSON array of names, or
+// a stream of JSON objects (one per line, as in the 'compact' format).
+// Classification is taken from the bestResult field of a name, or from
+// the name object itself. Names without classification produce empty
+// hierarchies that are ignored by New.
+func FromVerification(r io.Reader) ([]Hierarchy, error) {
+	var res []Hierarchy
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var recs []verifRecord
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			err = json.Unmarshal(raw, &recs)
+		} else {
+			var rec verifRecord
+			err = json.Unmarshal(raw, &rec)
+			recs = []verifRecord{rec}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range recs {
+			h, err := v.hierarchy()
+			if err != nil {
+				return nil, fmt.Errorf("name %d: %w", len(res), err)
+			}
+			res = append(res, h)
+		}
+	}
+	return res, nil
+}
+
+// hierarchy converts a record into a Hierarchy.
+func (r verifRecord) hierarchy() (Hierarchy, error) {
+	cl := r.classification
+	if r.BestResult != nil {
+		cl = *r.BestResult
+	}
+	h, err := ParseHierarchy(
+		cl.ClassificationPath,
+		cl.ClassificationRanks,
+		cl.ClassificationIDs,
+	)
+	if errors.Is(err, ErrTooFewTaxons) {
+		return hierarchy{}, nil
+	}
+	return h, err
+}
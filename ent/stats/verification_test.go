@@ -0,0 +1,58 @@
+package stats_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gnames/gnstats/ent/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromVerification(t *testing.T) {
+	assert := assert.New(t)
+	owl := `{
+    "name": "Bubo bubo",
+    "matchType": "Exact",
+    "bestResult": {
+      "dataSourceId": 1,
+      "matchedName": "Bubo bubo (Linnaeus, 1758)",
+      "classificationPath": "Biota|Animalia|Chordata|Aves|Strigiformes|Strigidae|Bubo|Bubo bubo",
+      "classificationRanks": "unranked|kingdom|phylum|class|order|family|genus|species",
+      "classificationIds": "5T6MX|N|CH2|V2|466|GQX|3DQQ|NKSD"
+    }
+  }`
+	puma := `{
+    "name": "Puma concolor",
+    "bestResult": {
+      "classificationPath": "Biota|Animalia|Chordata|Mammalia|Carnivora|Felidae|Puma|Puma concolor",
+      "classificationRanks": "unranked|kingdom|phylum|class|order|family|genus|species",
+      "classificationIds": "5T6MX|N|CH2|6224G|VS|623RM|75F9|4QHKG"
+    }
+  }`
+	noMatch := `{"name": "Unknownia", "matchType": "NoMatch"}`
+
+	inputs := []string{
+		"[" + owl + "," + puma + "," + noMatch + "]",
+		strings.Join([]string{owl, puma, noMatch}, "\n"),
+	}
+	for _, v := range inputs {
+		hs, err := stats.FromVerification(strings.NewReader(v))
+		assert.Nil(err)
+		assert.Equal(3, len(hs))
+		assert.Empty(hs[2].Taxons())
+
+		res := stats.New(hs, 0.5)
+		assert.Equal(2, res.NamesNum)
+		assert.Equal("Animalia", res.Kingdom.Name)
+		assert.Equal("N", res.Kingdom.ID)
+		assert.Equal("Chordata", res.MainTaxon.Name)
+	}
+
+	bad := `{"bestResult": {"classificationPath": "Biota|Animalia", ` +
+		`"classificationRanks": "unranked", "classificationIds": "5T6MX|N"}}`
+	_, err := stats.FromVerification(strings.NewReader(bad))
+	assert.ErrorIs(err, stats.ErrRanksMismatch)
+
+	_, err = stats.FromVerification(strings.NewReader("{"))
+	assert.NotNil(err)
+}
@@ -0,0 +1,61 @@
+package stats
+
+import "fmt"
+
+// WarningCode identifies a kind of a problem found during the calculation
+// of Stats.
+type WarningCode string
+
+const (
+	// WarnDroppedNames means that some names did not qualify for
+	// the calculation.
+	WarnDroppedNames WarningCode = "droppedNames"
+
+	// WarnUnknownRanks means that some taxons had ranks that could not be
+	// recognized.
+	WarnUnknownRanks WarningCode = "unknownRanks"
+
+	// WarnTie means that several taxons shared the highest percentage of
+	// a rank, so the prevalent taxon of the rank is empty.
+	WarnTie WarningCode = "tie"
+
+	// WarnMultipleKingdoms means that names belong to more than one kingdom.
+	WarnMultipleKingdoms WarningCode = "multipleKingdoms"
+
+	// WarnMissingRank means that some names were dropped, because they did
+	// not have the rank required by OptRequireRank.
+	WarnMissingRank WarningCode = "missingRank"
+
+	// WarnNoNameData means that Stats were calculated from aggregated
+	// counts (see NewFromCounts), so accessors that need data of
+	// individual names return empty results.
+	WarnNoNameData WarningCode = "noNameData"
+)
+
+// Warning describes a problem that did not prevent the calculation of
+// Stats, but might affect the results.
+type Warning struct {
+	// Code is the kind of the problem.
+	Code WarningCode `json:"code"`
+
+	// Message is a human-readable description of the problem.
+	Message string `json:"message"`
+}
+
+// addWarning appends a warning with a formatted message to Stats.
+func (s *Stats) addWarning(code WarningCode, format string, a ...any) {
+	s.Warnings = append(s.Warnings, Warning{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// isUnranked checks if a rank string explicitly says that a taxon has no
+// rank, so the rank is not considered unrecognized.
+func isUnranked(rankStr string) bool {
+	switch rankStr {
+	case "", "unranked", "no rank", "clade":
+		return true
+	}
+	return false
+}
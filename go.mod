@@ -1,8 +1,12 @@
 module github.com/gnames/gnstats
 
-go 1.18
+go 1.21
 
-require github.com/stretchr/testify v1.7.1
+require (
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/stretchr/testify v1.7.1
+	golang.org/x/text v0.14.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect